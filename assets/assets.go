@@ -2,5 +2,5 @@ package assets
 
 import "embed"
 
-//go:embed "images"
+//go:embed "images" "templates"
 var Files embed.FS
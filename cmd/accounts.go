@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/transport/whatsapp"
+	"github.com/spf13/cobra"
+)
+
+var accountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "Manage the linked whatsapp accounts",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var listAccountsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the linked whatsapp accounts",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := db.OpenSqliteConn(whatsappDbPath())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		accounts, err := whatsapp.ListAccounts(cmd.Context(), conn)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROFILE\tJID\tNAME\tPLATFORM")
+		for _, account := range accounts {
+			profile := account.Profile
+			if profile == "" {
+				profile = "-"
+			}
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", profile, account.JID, account.PushName, account.Platform)
+		}
+
+		return tw.Flush()
+	},
+}
+
+var unlinkAccountCmd = &cobra.Command{
+	Use:   "unlink <profile|jid>",
+	Short: "Log a whatsapp account out and remove it from the store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := db.OpenSqliteConn(whatsappDbPath())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		account, err := whatsapp.Unlink(cmd.Context(), conn, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Account [%v] unlinked\n", account.JID)
+
+		return nil
+	},
+}
+
+func init() {
+	accountsCmd.AddCommand(listAccountsCmd)
+	accountsCmd.AddCommand(unlinkAccountCmd)
+}
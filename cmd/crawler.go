@@ -2,32 +2,110 @@ package cmd
 
 import (
 	"context"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal"
 	"github.com/apfelfrisch/zh-notify/internal/collect"
 	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+const DEFAULT_REMOVE_AFTER_MISSING_RUNS = 3
+
 var crawlEventsCmd = &cobra.Command{
 	Use:   "crawl",
-	Short: "Crawl Events from " + internal.URL,
+	Short: "Crawl Events from the configured sources",
 	Args:  cobra.ExactArgs(0), // Ensure exactly one argument is passed
 	RunE: func(cmd *cobra.Command, args []string) error {
-		repo, err := db.NewDbEventRepo()
-
+		database, err := openDatabase(cmd.Context())
 		if err != nil {
 			return err
 		}
+		defer database.Close()
+
+		return crawl(cmd.Context(), database.eventRepo(), database.crawlRunRepo())
+	},
+}
+
+func crawl(ctx context.Context, eventRepo db.EventRepository, crawlRunRepo db.CrawlRunRepository) error {
+	if path := viper.GetString("SOURCE_DEFINITIONS"); path != "" {
+		if err := collect.LoadDefinitions(path); err != nil {
+			return err
+		}
+	}
 
-		events, err := internal.CollectNewEvents()
+	events, err := internal.CollectNewEvents(sourceNames())
+
+	if err != nil {
+		return err
+	}
+
+	if err := saveEvents(ctx, eventRepo, events); err != nil {
+		return err
+	}
+
+	return recordCrawlRuns(ctx, crawlRunRepo, sourceNames(), events)
+}
+
+// recordCrawlRuns flags the events that are missing on the listing of their
+// source for REMOVE_AFTER_MISSING_RUNS consecutive runs (default 3). With
+// ANNOUNCE_REMOVED they are announced as cancelled.
+func recordCrawlRuns(ctx context.Context, crawlRunRepo db.CrawlRunRepository, sourceNames []string, events []collect.Event) error {
+	removeAfter := viper.GetInt("REMOVE_AFTER_MISSING_RUNS")
+	if removeAfter <= 0 {
+		removeAfter = DEFAULT_REMOVE_AFTER_MISSING_RUNS
+	}
 
+	for _, name := range sourceNames {
+		links := lo.FilterMap(events, func(event collect.Event, _ int) (string, bool) {
+			return strings.TrimSpace(event.Link), event.Source == name
+		})
+
+		// An empty listing is more likely a broken page than a cancelled season
+		if len(links) == 0 {
+			log.Printf("No events found for [%v], skip the removal check", name)
+			continue
+		}
+
+		removed, err := crawlRunRepo.Record(ctx, db.CrawlRunResult{
+			Source:          name,
+			Links:           links,
+			RemoveAfter:     removeAfter,
+			AnnounceRemoved: viper.GetBool("ANNOUNCE_REMOVED"),
+			Now:             time.Now(),
+		})
 		if err != nil {
 			return err
 		}
 
-		return saveEvents(cmd.Context(), repo, events)
-	},
+		for _, event := range removed {
+			log.Printf("[%v] was removed from the listing of [%v]", event.Name, name)
+		}
+	}
+
+	return nil
+}
+
+// sourceNames reads the comma separated SOURCES list, falling back to the
+// Zollhaus when nothing is configured.
+func sourceNames() []string {
+	var names []string
+
+	for _, name := range strings.Split(viper.GetString("SOURCES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return []string{"zollhaus"}
+	}
+
+	return names
 }
 
 func saveEvents(ctx context.Context, eventRepo db.EventRepository, events []collect.Event) error {
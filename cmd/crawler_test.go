@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"database/sql"
-	"os"
 	"testing"
 	"time"
 
@@ -19,14 +18,15 @@ const DBConnection = ":memory:"
 
 func prepareConnection() *sql.DB {
 	conn, _ := sql.Open(DBProvider, ":memory:")
-	data, _ := os.ReadFile("../schema.sql")
-	conn.Exec(string(data))
+	// Every connection would get its own in-memory database
+	conn.SetMaxOpenConns(1)
+	db.Migrate(context.Background(), conn)
 
 	return conn
 }
 
 func TestSaveEvents(t *testing.T) {
-	eventTmpl := collect.Event{Name: "event-1", Place: "place-1", Status: "available-1", Link: "link-1", Date: time.Now()}
+	eventTmpl := collect.Event{Name: "event-1", Place: "place-1", Status: "available-1", Link: "link-1", Date: time.Now(), Source: "source-1"}
 
 	t.Run("write new event", func(t *testing.T) {
 		repo := db.NewEventRepoFromConn(prepareConnection())
@@ -44,6 +44,7 @@ func TestSaveEvents(t *testing.T) {
 		assert.Equal(t, eventTmpl.Place, event.Place)
 		assert.Equal(t, eventTmpl.Status, event.Status)
 		assert.Equal(t, eventTmpl.Link, event.Link)
+		assert.Equal(t, eventTmpl.Source, event.Source)
 		assert.Equal(t, eventTmpl.Date.Format("02.01.06"), event.Date.Format("02.01.06"))
 		assert.False(t, event.ReportedAtNew.Valid)
 		assert.False(t, event.ReportedAtUpcoming.Valid)
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/db/postgres"
+	"github.com/spf13/viper"
+)
+
+type eventRepository interface {
+	db.EventRepository
+	GetMetadataFailures(ctx context.Context, eventId int64) ([]db.MetadataFailure, error)
+	RecordMetadataFailure(ctx context.Context, eventId int64, rawResponse string, reason string) error
+}
+
+type lookupCache interface {
+	db.LookupCacheRepository
+	Prune(ctx context.Context) error
+}
+
+// database holds the connection of the repositories, to Postgres if
+// POSTGRES_DSN is set and to the SQLite database otherwise.
+type database struct {
+	conn     *sql.DB
+	postgres bool
+}
+
+func openDatabase(ctx context.Context) (*database, error) {
+	if dsn := viper.GetString("POSTGRES_DSN"); dsn != "" {
+		conn, err := postgres.Open(ctx, dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		return &database{conn: conn, postgres: true}, nil
+	}
+
+	conn, err := db.NewSqliteConn(dbPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{conn: conn}, nil
+}
+
+func (d *database) Close() error {
+	return d.conn.Close()
+}
+
+func (d *database) eventRepo() eventRepository {
+	if d.postgres {
+		return postgres.NewEventRepoFromConn(d.conn)
+	}
+
+	return db.NewEventRepoFromConn(d.conn)
+}
+
+func (d *database) outboxRepo() db.OutboxRepository {
+	if d.postgres {
+		return postgres.NewOutboxRepoFromConn(d.conn)
+	}
+
+	return db.NewOutboxRepoFromConn(d.conn)
+}
+
+func (d *database) crawlRunRepo() db.CrawlRunRepository {
+	if d.postgres {
+		return postgres.NewCrawlRunRepoFromConn(d.conn)
+	}
+
+	return db.NewCrawlRunRepoFromConn(d.conn)
+}
+
+// lookupCache returns the cache of the metadata lookups, refresh ignores the
+// stored entries.
+func (d *database) lookupCache(refresh bool) lookupCache {
+	if d.postgres {
+		cache := postgres.NewLookupCacheRepoFromConn(d.conn)
+		cache.Refresh = refresh
+		return cache
+	}
+
+	cache := db.NewLookupCacheRepoFromConn(d.conn)
+	cache.Refresh = refresh
+	return cache
+}
+
+// sqliteConn returns the connection the whatsapp store may share, there is
+// none on Postgres.
+func (d *database) sqliteConn() *sql.DB {
+	if d.postgres {
+		return nil
+	}
+
+	return d.conn
+}
+
+// newEventRepo opens the event repository of the configured database.
+func newEventRepo(ctx context.Context) (eventRepository, error) {
+	database, err := openDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return database.eventRepo(), nil
+}
+
+func dbPath() string {
+	if path := viper.GetString("DB_PATH"); path != "" {
+		return path
+	}
+
+	return db.DEFAULT_PATH
+}
+
+// whatsappDbPath returns the path of the whatsapp device store, it shares the
+// event database unless WHATSAPP_DB_PATH is set.
+func whatsappDbPath() string {
+	if path := viper.GetString("WHATSAPP_DB_PATH"); path != "" {
+		return path
+	}
+
+	return dbPath()
+}
+
+// whatsappStoreConn opens the whatsapp device store, the given connection of
+// the event database is reused if both live in the same file. Without a
+// connection, e.g. on Postgres, the store is always opened.
+func whatsappStoreConn(conn *sql.DB) (*sql.DB, error) {
+	if conn != nil && sameFile(whatsappDbPath(), dbPath()) {
+		return conn, nil
+	}
+
+	return db.OpenSqliteConn(whatsappDbPath())
+}
+
+func sameFile(a string, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+
+	return absA == absB
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabasePaths(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("DB_PATH", "")
+		viper.Set("WHATSAPP_DB_PATH", "")
+	})
+
+	t.Run("defaults to the database in the working directory", func(t *testing.T) {
+		viper.Set("DB_PATH", "")
+		viper.Set("WHATSAPP_DB_PATH", "")
+
+		assert.Equal(t, db.DEFAULT_PATH, dbPath())
+		assert.Equal(t, db.DEFAULT_PATH, whatsappDbPath())
+	})
+
+	t.Run("whatsapp store shares the configured event database", func(t *testing.T) {
+		viper.Set("DB_PATH", "/var/lib/zh-notify/events.sqlite")
+		viper.Set("WHATSAPP_DB_PATH", "")
+
+		assert.Equal(t, "/var/lib/zh-notify/events.sqlite", whatsappDbPath())
+	})
+
+	t.Run("whatsapp store in a separate file", func(t *testing.T) {
+		dir := t.TempDir()
+		viper.Set("DB_PATH", filepath.Join(dir, "events.sqlite"))
+		viper.Set("WHATSAPP_DB_PATH", filepath.Join(dir, "whatsapp.sqlite"))
+
+		conn, err := db.NewSqliteConn(dbPath())
+		assert.Nil(t, err)
+		defer conn.Close()
+
+		store, err := whatsappStoreConn(conn)
+		assert.Nil(t, err)
+		defer store.Close()
+
+		assert.NotSame(t, conn, store)
+		assert.FileExists(t, filepath.Join(dir, "events.sqlite"))
+	})
+
+	t.Run("relative and absolute paths of the same file share the connection", func(t *testing.T) {
+		abs, _ := filepath.Abs("database.sqlite")
+		viper.Set("DB_PATH", "database.sqlite")
+		viper.Set("WHATSAPP_DB_PATH", abs)
+
+		conn := prepareConnection()
+		defer conn.Close()
+
+		store, err := whatsappStoreConn(conn)
+		assert.Nil(t, err)
+		assert.Same(t, conn, store)
+	})
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// eventField maps a flag of event set to the metadata field it locks.
+type eventField struct {
+	flag  string
+	field string
+}
+
+var eventFields = []eventField{
+	{"artist", db.FIELD_ARTIST},
+	{"category", db.FIELD_CATEGORY},
+	{"artist-url", db.FIELD_ARTIST_URL},
+	{"image-url", db.FIELD_ARTIST_IMG_URL},
+}
+
+var eventCmd = &cobra.Command{
+	Use:   "event",
+	Short: "Review and correct the metadata of events",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var setEventCmd = &cobra.Command{
+	Use:   "set <id|link>",
+	Short: "Set the metadata of an event by hand",
+	Long:  "Set the metadata of an event by hand. The set fields are locked, the metadata enrichment and the crawler leave them alone until they are unlocked.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		overrides := map[string]string{}
+		for _, ef := range eventFields {
+			if cmd.Flags().Changed(ef.flag) {
+				overrides[ef.field], _ = cmd.Flags().GetString(ef.flag)
+			}
+		}
+
+		unlock, _ := cmd.Flags().GetStringSlice("unlock")
+
+		if len(overrides) == 0 && len(unlock) == 0 {
+			return errors.New("Nothing to set, pass --artist, --category, --artist-url, --image-url or --unlock")
+		}
+
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return setEvent(cmd.Context(), repo, cmd.OutOrStdout(), args[0], overrides, unlock)
+	},
+}
+
+var showEventCmd = &cobra.Command{
+	Use:   "show <id|link>",
+	Short: "Show an event with its metadata",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		event, err := findEvent(cmd.Context(), repo, args[0])
+		if err != nil {
+			return err
+		}
+
+		return printEvent(cmd.OutOrStdout(), event)
+	},
+}
+
+var listEventsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the upcoming events with their metadata",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		locked, _ := cmd.Flags().GetBool("locked")
+
+		fromDate := time.Now()
+		if since != "" {
+			parsed, err := time.ParseInLocation(EXPORT_DATE_FORMAT, since, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --since date: %w", err)
+			}
+			fromDate = parsed
+		}
+
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return listEvents(cmd.Context(), repo, cmd.OutOrStdout(), fromDate, locked)
+	},
+}
+
+func init() {
+	setEventCmd.Flags().String("artist", "", "Artist of the event")
+	setEventCmd.Flags().String("category", "", "Category of the event, one of "+strings.Join(llm.CATEGORIES, ", "))
+	setEventCmd.Flags().String("artist-url", "", "Link to the artist")
+	setEventCmd.Flags().String("image-url", "", "Image of the artist")
+	setEventCmd.Flags().StringSlice("unlock", nil, "Hand these fields back to the metadata enrichment, e.g. artist,image-url")
+
+	listEventsCmd.Flags().String("since", "", "List events from this date on (YYYY-MM-DD), defaults to today")
+	listEventsCmd.Flags().Bool("locked", false, "Only list events with fields set by hand")
+
+	eventCmd.AddCommand(setEventCmd)
+	eventCmd.AddCommand(showEventCmd)
+	eventCmd.AddCommand(listEventsCmd)
+}
+
+// setEvent unlocks the fields first and then locks the overridden ones. The
+// overrides are keyed by the field, unlock names the fields by their flag.
+func setEvent(ctx context.Context, repo db.EventRepository, w io.Writer, ref string, overrides map[string]string, unlock []string) error {
+	if category, ok := overrides[db.FIELD_CATEGORY]; ok && category != "" && !lo.Contains(llm.CATEGORIES, category) {
+		return fmt.Errorf("Unknown category [%v], use one of %v", category, strings.Join(llm.CATEGORIES, ", "))
+	}
+
+	event, err := findEvent(ctx, repo, ref)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range unlock {
+		ef, ok := lo.Find(eventFields, func(candidate eventField) bool {
+			return candidate.flag == strings.TrimSpace(flag)
+		})
+		if !ok {
+			return fmt.Errorf("Unknown field [%v]", flag)
+		}
+
+		if err := event.Unlock(ef.field); err != nil {
+			return err
+		}
+	}
+
+	for _, ef := range eventFields {
+		if value, ok := overrides[ef.field]; ok {
+			if err := event.Override(ef.field, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := repo.Save(ctx, event); err != nil {
+		return err
+	}
+
+	return printEvent(w, event)
+}
+
+func printEvent(w io.Writer, event db.Event) error {
+	fmt.Fprintf(w, "#%d %v\n%v\n\n", event.ID, event.Name, event.Link)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "date\t%v\n", event.Date.Local().Format(HISTORY_TIME_FORMAT))
+	fmt.Fprintf(tw, "place\t%v\n", historyValue(event.Place))
+	fmt.Fprintf(tw, "status\t%v\n", historyValue(event.Status))
+
+	values := map[string]string{
+		db.FIELD_ARTIST:         event.Artist.String,
+		db.FIELD_CATEGORY:       event.Category.String,
+		db.FIELD_ARTIST_URL:     event.ArtistUrl.String,
+		db.FIELD_ARTIST_IMG_URL: event.ArtistImgUrl.String,
+	}
+
+	for _, ef := range eventFields {
+		if event.Locked(ef.field) {
+			fmt.Fprintf(tw, "%v\t%v\t(locked)\n", ef.flag, historyValue(values[ef.field]))
+			continue
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\n", ef.flag, historyValue(values[ef.field]))
+	}
+
+	return tw.Flush()
+}
+
+func listEvents(ctx context.Context, repo db.EventRepository, w io.Writer, fromDate time.Time, onlyLocked bool) error {
+	events, err := repo.GetEventsBetween(ctx, fromDate, fromDate.AddDate(10, 0, 0))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tNAME\tARTIST\tCATEGORY\tLOCKED")
+
+	for _, event := range events {
+		if onlyLocked && len(event.Locks()) == 0 {
+			continue
+		}
+
+		locks := lo.FilterMap(eventFields, func(ef eventField, _ int) (string, bool) {
+			return ef.flag, event.Locked(ef.field)
+		})
+
+		fmt.Fprintf(
+			tw,
+			"%d\t%v\t%v\t%v\t%v\t%v\n",
+			event.ID,
+			event.Date.Local().Format(HISTORY_TIME_FORMAT),
+			event.Name,
+			lo.Ternary(event.Artist.String != "", event.Artist.String, "-"),
+			lo.Ternary(event.Category.String != "", event.Category.String, "-"),
+			lo.Ternary(len(locks) > 0, strings.Join(locks, ","), "-"),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+)
+
+func TestSetEvent(t *testing.T) {
+	ctx := context.Background()
+	date := time.Now().AddDate(0, 0, 5)
+
+	prepare := func() *db.EventRepo {
+		repo := db.NewEventRepoFromConn(prepareConnection())
+		saveEvents(ctx, repo, []collect.Event{{Name: "event-1", Link: "link-1", Date: date, ArtistImgUrl: "crawled-img-url"}})
+		saveEvents(ctx, repo, []collect.Event{{Name: "event-2", Link: "link-2", Date: date}})
+
+		return repo
+	}
+
+	t.Run("lock the set fields", func(t *testing.T) {
+		repo := prepare()
+		buf := bytes.Buffer{}
+
+		err := setEvent(ctx, repo, &buf, "link-1", map[string]string{db.FIELD_ARTIST: "artist-1", db.FIELD_ARTIST_IMG_URL: "img-url"}, nil)
+		assert.Nil(t, err)
+		assert.Regexp(t, "artist +artist-1 +\\(locked\\)", buf.String())
+		assert.Regexp(t, "image-url +img-url +\\(locked\\)", buf.String())
+
+		// A new crawl keeps the image set by hand
+		saveEvents(ctx, repo, []collect.Event{{Name: "event-1", Link: "link-1", Date: date, ArtistImgUrl: "crawled-img-url"}})
+
+		event, _ := repo.GetByLink(ctx, "link-1")
+		assert.Equal(t, "artist-1", event.Artist.String)
+		assert.Equal(t, "img-url", event.ArtistImgUrl.String)
+		assert.Equal(t, []string{db.FIELD_ARTIST, db.FIELD_ARTIST_IMG_URL}, event.Locks())
+
+		revisions, _ := repo.GetRevisions(ctx, event.ID)
+		assert.Contains(t, fields(revisions), "locked_fields")
+	})
+
+	t.Run("unlock fields", func(t *testing.T) {
+		repo := prepare()
+
+		assert.Nil(t, setEvent(ctx, repo, &bytes.Buffer{}, "1", map[string]string{db.FIELD_ARTIST: "artist-1", db.FIELD_CATEGORY: "concert"}, nil))
+		assert.Nil(t, setEvent(ctx, repo, &bytes.Buffer{}, "1", nil, []string{"artist"}))
+
+		event, _ := repo.GetById(ctx, 1)
+		assert.False(t, event.Artist.Valid)
+		assert.Equal(t, []string{db.FIELD_CATEGORY}, event.Locks())
+	})
+
+	t.Run("reject unknown categories and fields", func(t *testing.T) {
+		repo := prepare()
+
+		assert.ErrorContains(t, setEvent(ctx, repo, &bytes.Buffer{}, "1", map[string]string{db.FIELD_CATEGORY: "jazz"}, nil), "jazz")
+		assert.ErrorContains(t, setEvent(ctx, repo, &bytes.Buffer{}, "1", nil, []string{"genre"}), "genre")
+	})
+
+	t.Run("list events with locked fields", func(t *testing.T) {
+		repo := prepare()
+		assert.Nil(t, setEvent(ctx, repo, &bytes.Buffer{}, "2", map[string]string{db.FIELD_CATEGORY: "party"}, nil))
+
+		buf := bytes.Buffer{}
+		assert.Nil(t, listEvents(ctx, repo, &buf, time.Now(), true))
+
+		assert.Regexp(t, "event-2 +- +party +category", buf.String())
+		assert.NotContains(t, buf.String(), "event-1")
+	})
+}
+
+func fields(revisions []db.EventRevision) []string {
+	var fields []string
+	for _, revision := range revisions {
+		fields = append(fields, revision.Field)
+	}
+
+	return fields
+}
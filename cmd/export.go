@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/ics"
+	"github.com/spf13/cobra"
+)
+
+const EXPORT_DATE_FORMAT = "2006-01-02"
+
+var exportCmd = &cobra.Command{
+	Use:   "export [ics]",
+	Short: "Export the crawled events",
+	Args:  validateExportArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		since, _ := cmd.Flags().GetString("since")
+
+		fromDate := time.Now()
+		if since != "" {
+			parsed, err := time.ParseInLocation(EXPORT_DATE_FORMAT, since, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --since date: %w", err)
+			}
+			fromDate = parsed
+		}
+
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+
+		return exportIcs(cmd.Context(), repo, w, fromDate)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "Write to file instead of stdout")
+	exportCmd.Flags().String("since", "", "Export events from this date on (YYYY-MM-DD), defaults to today")
+}
+
+func validateExportArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("exactly one argument is required: 'ics'")
+	}
+	if args[0] != "ics" {
+		return fmt.Errorf("invalid argument: %s. Allowed value is 'ics'", args[0])
+	}
+	return nil
+}
+
+func exportIcs(ctx context.Context, eventRepo db.EventRepository, w io.Writer, fromDate time.Time) error {
+	events, err := eventRepo.GetEventsBetween(ctx, fromDate, fromDate.AddDate(10, 0, 0))
+
+	if err != nil {
+		return err
+	}
+
+	sequences, err := ics.Sequences(ctx, eventRepo, events)
+	if err != nil {
+		return err
+	}
+
+	return ics.Write(w, events, sequences, time.Now())
+}
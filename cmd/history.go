@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const HISTORY_TIME_FORMAT = "2006-01-02 15:04"
+
+var historyCmd = &cobra.Command{
+	Use:   "history <id|link>",
+	Short: "Show the change history of an event",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return printHistory(cmd.Context(), repo, cmd.OutOrStdout(), args[0])
+	},
+}
+
+func printHistory(ctx context.Context, repo eventRepository, w io.Writer, ref string) error {
+	event, err := findEvent(ctx, repo, ref)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := repo.GetRevisions(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "#%d %v\n%v\n\n", event.ID, event.Name, event.Link)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%v\tcreated\t\n", event.CreatedAt.Local().Format(HISTORY_TIME_FORMAT))
+
+	for _, revision := range revisions {
+		fmt.Fprintf(
+			tw,
+			"%v\t%v\t%v -> %v\n",
+			revision.ChangedAt.Local().Format(HISTORY_TIME_FORMAT),
+			revision.Field,
+			historyValue(revision.OldValue),
+			historyValue(revision.NewValue),
+		)
+	}
+
+	// Events removed before the removal was recorded as a revision
+	removalRecorded := lo.ContainsBy(revisions, func(revision db.EventRevision) bool {
+		return revision.Field == "removed_at"
+	})
+
+	if event.RemovedAt.Valid && !removalRecorded {
+		fmt.Fprintf(tw, "%v\tremoved from the listing\t\n", event.RemovedAt.Time.Local().Format(HISTORY_TIME_FORMAT))
+	}
+
+	return tw.Flush()
+}
+
+// findEvent looks the event up by its id, or by its link otherwise.
+func findEvent(ctx context.Context, repo db.EventRepository, ref string) (db.Event, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		event, err := repo.GetById(ctx, id)
+		if err != nil {
+			return db.Event{}, fmt.Errorf("Could not find event [%v]: %w", ref, err)
+		}
+
+		return event, nil
+	}
+
+	event, err := repo.GetByLink(ctx, ref)
+	if err != nil {
+		return db.Event{}, fmt.Errorf("Could not find event [%v]: %w", ref, err)
+	}
+
+	return event, nil
+}
+
+func historyValue(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+
+	return value
+}
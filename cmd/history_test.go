@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+)
+
+func TestPrintHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := db.NewEventRepoFromConn(prepareConnection())
+	date := time.Now().AddDate(0, 0, 5)
+
+	saveEvents(ctx, repo, []collect.Event{{Name: "event-1", Place: "place-1", Status: "Tickets", Link: "link-1", Date: date}})
+	saveEvents(ctx, repo, []collect.Event{{Name: "event-1", Place: "place-2", Status: "Tickets", Link: "link-1", Date: date}})
+	saveEvents(ctx, repo, []collect.Event{{Name: "event-1", Place: "place-2", Status: "Tickets", Link: "link-1", Date: date.AddDate(0, 0, 7)}})
+
+	for _, ref := range []string{"1", "link-1"} {
+		buf := bytes.Buffer{}
+
+		assert.Nil(t, printHistory(ctx, repo, &buf, ref))
+
+		assert.Contains(t, buf.String(), "#1 event-1")
+		assert.Contains(t, buf.String(), "created")
+		assert.Regexp(t, "place +place-1 -> place-2", buf.String())
+		assert.Contains(t, buf.String(), " "+date.UTC().Format(time.RFC3339)+" -> "+date.AddDate(0, 0, 7).UTC().Format(time.RFC3339))
+		assert.Contains(t, buf.String(), "postponed_date  (empty) -> "+date.UTC().Format(time.RFC3339))
+	}
+
+	assert.ErrorContains(t, printHistory(ctx, repo, &bytes.Buffer{}, "unknown"), "unknown")
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"io"
+	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal/db"
 	"github.com/apfelfrisch/zh-notify/internal/transport/whatsapp"
@@ -12,31 +13,55 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const DEFAULT_LINK_TIMEOUT = 5 * time.Minute
+
 var linkAccountCmd = &cobra.Command{
 	Use:   "link",
 	Short: "Link a whatsapp account, to send events",
 	Args:  cobra.ExactArgs(0), // Ensure exactly one argument is passed
 	RunE: func(cmd *cobra.Command, args []string) error {
-		conn, err := db.NewSqliteConn()
+		conn, err := db.OpenSqliteConn(whatsappDbPath())
 
 		if err != nil {
 			return err
 		}
+		defer conn.Close()
+
+		name, _ := cmd.Flags().GetString("name")
+		phone, _ := cmd.Flags().GetString("phone")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
 
-		return linkAccount(cmd.Context(), conn)
+		return linkAccount(ctx, conn, cmd.OutOrStdout(), name, phone)
 	},
 }
 
-func linkAccount(ctx context.Context, db *sql.DB) error {
-	err := whatsapp.Register(ctx, db, func(qrCode string) {
-		qrterminal.GenerateHalfBlock(qrCode, qrterminal.L, os.Stdout)
+func init() {
+	linkAccountCmd.Flags().String("name", whatsapp.DEFAULT_PROFILE, "Profile name of the account, used as whatsapp/<name> in destinations")
+	linkAccountCmd.Flags().String("phone", "", "Link with a pairing code for this phone number (international format) instead of a QR code")
+	linkAccountCmd.Flags().Duration("timeout", DEFAULT_LINK_TIMEOUT, "Give up if the account isn't linked in time")
+}
+
+func linkAccount(ctx context.Context, db *sql.DB, w io.Writer, name string, phone string) error {
+	jid, err := whatsapp.Register(ctx, db, whatsapp.LinkParams{
+		Profile: name,
+		Phone:   phone,
+		OnQRCode: func(code string) {
+			fmt.Fprintln(w, "Scan the QR code in WhatsApp under Linked devices:")
+			qrterminal.GenerateHalfBlock(code, qrterminal.L, w)
+		},
+		OnPairingCode: func(code string) {
+			fmt.Fprintf(w, "Enter the code %v in WhatsApp under Linked devices > Link with phone number\n", code)
+		},
 	})
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Account linked successful")
+	fmt.Fprintf(w, "Account [%v] linked successful as [%v]\n", name, jid)
 
 	return nil
 }
@@ -3,53 +3,134 @@ package cmd
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal"
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/collect/openai"
+	"github.com/apfelfrisch/zh-notify/internal/collect/rules"
 	"github.com/apfelfrisch/zh-notify/internal/db"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// Events whose metadata could not be read are skipped for a while, every
+// attempt costs up to MAX_ATTEMPTS llm requests.
+const METADATA_RETRY_AFTER = 7 * 24 * time.Hour
+
 var updateMetadataCmd = &cobra.Command{
 	Use:   "meta",
 	Short: "Get Metadata for new Events",
 	Args:  cobra.ExactArgs(0), // Ensure exactly one argument is passed
 	RunE: func(cmd *cobra.Command, args []string) error {
-		chatGptToken := viper.GetString("CHATGPT_TOKEN")
-		if chatGptToken == "" {
-			return errors.New("Could not read SENDER_JID from env")
-		}
+		refresh, _ := cmd.Flags().GetBool("refresh")
 
-		spotifyId := viper.GetString("SPOTIFY_ID")
-		if chatGptToken == "" {
-			return errors.New("Could not read SENDER_JID from env")
+		database, err := openDatabase(cmd.Context())
+		if err != nil {
+			return err
 		}
+		defer database.Close()
 
-		sporitySecret := viper.GetString("SPOTIFY_SECRET")
-		if chatGptToken == "" {
-			return errors.New("Could not read SENDER_JID from env")
+		cache, err := newLookupCache(cmd.Context(), database, refresh)
+		if err != nil {
+			return err
 		}
 
-		repo, err := db.NewDbEventRepo()
+		collector, err := newSyncCollector(cache)
 		if err != nil {
 			return err
 		}
 
-		return updateMetadata(
-			cmd.Context(),
-			repo,
-			internal.NewSyncEventCollector(chatGptToken, spotifyId, sporitySecret),
-		)
+		return updateMetadata(cmd.Context(), database.eventRepo(), collector, refresh)
 	},
 }
 
-func updateMetadata(ctx context.Context, eventRepo db.EventRepository, service *internal.SyncCollector) error {
+func init() {
+	updateMetadataCmd.Flags().Bool("refresh", false, "Ignore the cached lookups and recent failures and ask the llm and spotify again")
+}
+
+// newLookupCache opens the lookup cache of the database and prunes its
+// expired entries.
+func newLookupCache(ctx context.Context, database *database, refresh bool) (db.LookupCacheRepository, error) {
+	cache := database.lookupCache(refresh)
+
+	if err := cache.Prune(ctx); err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// newSyncCollector loads the rules from META_RULES, or the built-in ones.
+// The llm and spotify are only asked when they are configured, without them
+// the metadata is filled from the rules alone.
+func newSyncCollector(cache db.LookupCacheRepository) (*internal.SyncCollector, error) {
+	ruleSet, err := rules.Load(viper.GetString("META_RULES"))
+	if err != nil {
+		return nil, err
+	}
+
+	var provider llm.Provider
+	if llmConfigured() {
+		if provider, err = newLlmProvider(); err != nil {
+			return nil, err
+		}
+	}
+
+	spotifyId := viper.GetString("SPOTIFY_ID")
+	sporitySecret := viper.GetString("SPOTIFY_SECRET")
+	if spotifyId != "" && sporitySecret == "" {
+		return nil, errors.New("Could not read SPOTIFY_SECRET from env")
+	}
+
+	return internal.NewSyncEventCollector(ruleSet, provider, cache, spotifyId, sporitySecret), nil
+}
+
+func llmConfigured() bool {
+	return viper.GetString("LLM_API_KEY") != "" ||
+		viper.GetString("CHATGPT_TOKEN") != "" ||
+		viper.GetString("LLM_BASE_URL") != ""
+}
+
+// newLlmProvider reads the model from LLM_MODEL and the endpoint from
+// LLM_BASE_URL. Only the OpenAI API needs a token, it is read from
+// LLM_API_KEY or the former CHATGPT_TOKEN.
+func newLlmProvider() (llm.Provider, error) {
+	token := viper.GetString("LLM_API_KEY")
+	if token == "" {
+		token = viper.GetString("CHATGPT_TOKEN")
+	}
+
+	baseUrl := viper.GetString("LLM_BASE_URL")
+	if baseUrl == "" && token == "" {
+		return nil, errors.New("Could not read LLM_API_KEY or CHATGPT_TOKEN from env")
+	}
+
+	return openai.New(openai.Config{
+		Token:   token,
+		BaseUrl: baseUrl,
+		Model:   viper.GetString("LLM_MODEL"),
+	}), nil
+}
+
+// updateMetadata fills the missing metadata of the events. If the provider
+// keeps answering with invalid metadata, the raw response is stored and the
+// event is left untouched until METADATA_RETRY_AFTER has passed, or retryFailed
+// is set.
+func updateMetadata(ctx context.Context, eventRepo eventRepository, service *internal.SyncCollector, retryFailed bool) error {
 	events, err := eventRepo.GetNakedEvents(ctx)
 
 	if err != nil {
 		return err
 	}
 
+	if !retryFailed {
+		if events, err = skipRecentFailures(ctx, eventRepo, events, time.Now()); err != nil {
+			return err
+		}
+	}
+
 	if len(events) == 0 {
 		return nil
 	}
@@ -59,7 +140,19 @@ func updateMetadata(ctx context.Context, eventRepo db.EventRepository, service *
 	}
 
 	for _, event := range events {
-		if err := service.Sync(&event); err != nil {
+		err := service.Sync(&event)
+
+		var invalid *llm.InvalidResponseError
+		if errors.As(err, &invalid) {
+			log.Printf("Could not read the metadata of [%v]: %v", event.Name, err)
+
+			if err := eventRepo.RecordMetadataFailure(ctx, event.ID, invalid.Raw, invalid.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil {
 			return err
 		}
 
@@ -68,3 +161,24 @@ func updateMetadata(ctx context.Context, eventRepo db.EventRepository, service *
 
 	return nil
 }
+
+// skipRecentFailures drops the events whose last metadata failure is more
+// recent than METADATA_RETRY_AFTER.
+func skipRecentFailures(ctx context.Context, eventRepo eventRepository, events []db.Event, now time.Time) ([]db.Event, error) {
+	var retry []db.Event
+
+	for _, event := range events {
+		failures, err := eventRepo.GetMetadataFailures(ctx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(failures) > 0 && failures[len(failures)-1].CreatedAt.After(now.Add(-METADATA_RETRY_AFTER)) {
+			continue
+		}
+
+		retry = append(retry, event)
+	}
+
+	return retry, nil
+}
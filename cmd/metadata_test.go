@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal"
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/collect/rules"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type invalidProvider struct {
+	calls int
+}
+
+func (p *invalidProvider) Extract(ctx context.Context, title string) (llm.Metadata, error) {
+	p.calls++
+	return llm.Metadata{}, &llm.InvalidResponseError{Raw: "{}", Err: errors.New("Missing artist")}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	prepare := func(t *testing.T) (*db.EventRepo, *invalidProvider, *internal.SyncCollector) {
+		repo := db.NewEventRepoFromConn(prepareConnection())
+		assert.Nil(t, repo.Save(ctx, db.Event{Name: "Unreadable", Link: "link-1", Date: time.Now().AddDate(0, 0, 1)}))
+
+		ruleSet, err := rules.Load("")
+		assert.Nil(t, err)
+
+		provider := &invalidProvider{}
+
+		return repo, provider, internal.NewSyncEventCollector(ruleSet, provider, nil, "", "")
+	}
+
+	t.Run("skip events that failed recently", func(t *testing.T) {
+		repo, provider, collector := prepare(t)
+
+		assert.Nil(t, updateMetadata(ctx, repo, collector, false))
+		assert.Equal(t, llm.MAX_ATTEMPTS, provider.calls)
+
+		assert.Nil(t, updateMetadata(ctx, repo, collector, false))
+		assert.Equal(t, llm.MAX_ATTEMPTS, provider.calls)
+
+		failures, _ := repo.GetMetadataFailures(ctx, 1)
+		assert.Len(t, failures, 1)
+	})
+
+	t.Run("retry failed events on request", func(t *testing.T) {
+		repo, provider, collector := prepare(t)
+
+		assert.Nil(t, updateMetadata(ctx, repo, collector, false))
+		assert.Nil(t, updateMetadata(ctx, repo, collector, true))
+
+		assert.Equal(t, 2*llm.MAX_ATTEMPTS, provider.calls)
+	})
+}
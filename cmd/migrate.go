@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/spf13/cobra"
+)
+
+const MIGRATE_UP = "up"
+const MIGRATE_DOWN = "down"
+const MIGRATE_STATUS = "status"
+
+var migrateCmd = &cobra.Command{
+	Use:       "migrate up|down|status",
+	Short:     "Apply, revert or list the database migrations",
+	Long:      "Apply, revert or list the database migrations. Pending migrations are also applied whenever the database is opened.",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{MIGRATE_UP, MIGRATE_DOWN, MIGRATE_STATUS},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := db.OpenSqliteConn(dbPath())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		migrator, err := db.NewMigrator(conn)
+		if err != nil {
+			return err
+		}
+
+		return migrate(cmd.Context(), migrator, cmd.OutOrStdout(), args[0])
+	},
+}
+
+func migrate(ctx context.Context, migrator *db.Migrator, w io.Writer, action string) error {
+	switch action {
+	case MIGRATE_UP:
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(w, "Applied %04d_%v\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(w, "Nothing to migrate")
+		}
+		return err
+	case MIGRATE_DOWN:
+		migration, reverted, err := migrator.Down(ctx)
+		if reverted {
+			fmt.Fprintf(w, "Reverted %04d_%v\n", migration.Version, migration.Name)
+		} else if err == nil {
+			fmt.Fprintln(w, "Nothing to revert")
+		}
+		return err
+	case MIGRATE_STATUS:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt.Valid {
+				applied = status.AppliedAt.Time.Local().Format(HISTORY_TIME_FORMAT)
+			}
+			fmt.Fprintf(tw, "%04d\t%v\t%v\n", status.Version, status.Name, applied)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("Unknown migrate action [%v]", action)
+}
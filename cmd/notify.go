@@ -4,83 +4,181 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+	"github.com/apfelfrisch/zh-notify/internal/transport/dryrun"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var notifyCmd = &cobra.Command{
-	Use:   "notify [upcoming|fresh]",
+	Use:   "notify [upcoming|fresh|digest|status|retry]",
 	Short: "Broadcast Zollhaus Events",
 	Args:  validateNotifyArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		transportFlag, _ := cmd.Flags().GetString("transport")
+
+		if args[0] == "retry" && dryRun {
+			return errors.New("--dry-run is not supported for 'retry'")
+		}
+
+		database, err := openDatabase(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		var names []string
+		var destinationsByKind map[string][]transport.Destination
+
+		if args[0] == "retry" {
+			names, err = dueTransports(cmd.Context(), database.outboxRepo(), transportFlag)
+		} else {
+			destinationsByKind, err = notifyDestinations([]string{args[0]}, transportFlag)
+			names = transportNames(destinationsByKind)
+		}
+		if err != nil {
+			return err
+		}
+
+		if args[0] != "retry" && len(destinationsByKind[args[0]]) == 0 {
+			return fmt.Errorf("No destinations configured in NOTIFY_%v_DESTINATIONS", strings.ToUpper(args[0]))
+		}
+
+		notificator, err := newNotificator(cmd, database, names, dryRun)
+		if err != nil {
+			return err
+		}
+		defer notificator.Close()
+
 		switch args[0] {
-		case "fresh":
-			return notifyFresh(cmd.Context())
-		case "upcoming":
-			return notifyMonthly(cmd.Context())
+		case "fresh", "upcoming", "status":
+			return notify(cmd.Context(), notificator, args[0], destinationsByKind[args[0]], dryRun)
+		case NOTIFY_DIGEST:
+			days, _ := cmd.Flags().GetInt("days")
+			if !cmd.Flags().Changed("days") {
+				days = digestDays()
+			}
+			return notifyDigest(cmd.Context(), notificator, destinationsByKind[NOTIFY_DIGEST], days, dryRun)
+		case "retry":
+			return notificator.ProcessOutbox(cmd.Context())
 		}
 
 		return errors.New("unexpected error occurred")
 	},
 }
 
+func init() {
+	notifyCmd.Flags().String("transport", "", "Send with 'whatsapp' or 'telegram', defaults to TRANSPORT or whatsapp")
+	notifyCmd.Flags().Bool("dry-run", false, "Print the messages and write the images to --output-dir instead of sending them")
+	notifyCmd.Flags().String("output-dir", "dry-run", "Directory for the images of a dry run")
+	notifyCmd.Flags().Int("days", internal.DIGEST_DAYS_AHEAD, "Days covered by the digest, defaults to DIGEST_DAYS")
+}
+
 func validateNotifyArgs(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		return errors.New("exactly one argument is required: 'upcoming' or 'fresh'")
+		return errors.New("exactly one argument is required: 'upcoming', 'fresh', 'digest', 'status' or 'retry'")
 	}
-	if args[0] != "upcoming" && args[0] != "fresh" {
-		return fmt.Errorf("invalid argument: %s. Allowed values are 'upcoming' or 'fresh'", args[0])
+	if !lo.Contains([]string{"upcoming", "fresh", NOTIFY_DIGEST, "status", "retry"}, args[0]) {
+		return fmt.Errorf("invalid argument: %s. Allowed values are 'upcoming', 'fresh', 'digest', 'status' or 'retry'", args[0])
 	}
 	return nil
 }
 
-func notifyMonthly(ctx context.Context) error {
-	senderJid := viper.GetString("SENDER_JID")
-	if senderJid == "" {
-		return errors.New("Could not read SENDER_JID from env")
+func newNotificator(cmd *cobra.Command, database *database, transportNames []string, dryRun bool) (*internal.Notificator, error) {
+	templates, err := newTemplates()
+	if err != nil {
+		return nil, err
 	}
 
-	monthlyChannel := viper.GetString("MONTHLY_CHANNEL_JID")
-	if monthlyChannel == "" {
-		return errors.New("Could not read SENDER_JID from env")
-	}
+	if !dryRun {
+		drivers, err := newDrivers(cmd.Context(), database.sqliteConn(), transportNames)
+		if err != nil {
+			return nil, err
+		}
 
-	notificator, err := internal.NewNotificator(ctx, senderJid)
+		return internal.NewNotificator(database.eventRepo(), database.outboxRepo(), drivers, templates), nil
+	}
 
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	driver, err := dryrun.New(cmd.OutOrStdout(), outputDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	notificator.SendMonthlyEvents(ctx, monthlyChannel)
+	drivers := map[string]transport.Driver{}
+	for _, name := range transportNames {
+		drivers[name] = driver
+	}
 
-	return nil
+	return internal.NewNotificator(database.eventRepo(), database.outboxRepo(), drivers, templates), nil
 }
 
-func notifyFresh(ctx context.Context) error {
-	senderJid := viper.GetString("SENDER_JID")
-	if senderJid == "" {
-		return errors.New("Could not read SENDER_JID from env")
+// dueTransports lists the transports of the messages waiting in the outbox,
+// a retry only connects those. The transport flag narrows them down.
+func dueTransports(ctx context.Context, outboxRepo db.OutboxRepository, transportFlag string) ([]string, error) {
+	messages, err := outboxRepo.GetDue(ctx, time.Now(), internal.MAX_SEND_ATTEMPTS)
+	if err != nil {
+		return nil, err
 	}
 
-	monthlyChannel := viper.GetString("MONTHLY_CHANNEL_JID")
-	if monthlyChannel == "" {
-		return errors.New("Could not read MONTHLY_CHANNEL_JID from env")
-	}
+	var names []string
 
-	justAddedChannel := viper.GetString("NEW_EVENTS_CHANNEL_JID")
-	if monthlyChannel == "" {
-		return errors.New("Could not read NEW_EVENTS_CHANNEL_JID from env")
+	for _, message := range messages {
+		transportName, _ := transport.SplitProfile(message.Transport)
+		if transportFlag == "" || message.Transport == transportFlag || transportName == transportFlag {
+			names = append(names, message.Transport)
+		}
 	}
 
-	notificator, err := internal.NewNotificator(ctx, senderJid)
+	names = lo.Uniq(names)
+	sort.Strings(names)
 
-	if err != nil {
-		return err
+	return names, nil
+}
+
+// newTemplates loads the message templates, the files in TEMPLATE_DIR
+// replace the embedded ones.
+func newTemplates() (*internal.Templates, error) {
+	return internal.LoadTemplates(viper.GetString("TEMPLATE_DIR"))
+}
+
+func notify(ctx context.Context, notificator *internal.Notificator, kind string, destinations []transport.Destination, dryRun bool) error {
+	switch {
+	case kind == db.OUTBOX_KIND_STATUS && dryRun:
+		return notificator.PreviewStatusChanges(ctx, destinations)
+	case kind == db.OUTBOX_KIND_STATUS:
+		return notificator.SendStatusChanges(ctx, destinations)
+	case kind == db.OUTBOX_KIND_UPCOMING && dryRun:
+		return notificator.PreviewMonthlyEvents(ctx, destinations)
+	case kind == db.OUTBOX_KIND_UPCOMING:
+		return notificator.SendMonthlyEvents(ctx, destinations)
+	case dryRun:
+		return notificator.PreviewFreshEvents(ctx, destinations)
+	default:
+		return notificator.SendFreshEvents(ctx, destinations)
 	}
+}
 
-	notificator.SendFreshEvents(ctx, justAddedChannel)
+func notifyDigest(ctx context.Context, notificator *internal.Notificator, destinations []transport.Destination, days int, dryRun bool) error {
+	if dryRun {
+		return notificator.PreviewDigest(ctx, destinations, time.Now(), days)
+	}
 
-	return nil
+	return notificator.SendDigest(ctx, destinations, time.Now(), days)
+}
+
+func digestDays() int {
+	if days := viper.GetInt("DIGEST_DAYS"); days > 0 {
+		return days
+	}
+
+	return internal.DIGEST_DAYS_AHEAD
 }
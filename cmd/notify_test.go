@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueTransports(t *testing.T) {
+	ctx := context.Background()
+	conn := prepareConnection()
+	defer conn.Close()
+
+	db.NewEventRepoFromConn(conn).Save(ctx, db.Event{Name: "event-1", Link: "link-1", Date: time.Now()})
+	outboxRepo := db.NewOutboxRepoFromConn(conn)
+
+	for _, name := range []string{"whatsapp", "whatsapp/promo", "telegram", "whatsapp"} {
+		outboxRepo.Enqueue(ctx, db.Outbox{EventID: 1, Kind: db.OUTBOX_KIND_DIGEST, Transport: name, Receiver: "receiver-" + name, Revision: "rev-1", NextRetryAt: time.Now()})
+	}
+	outboxRepo.Enqueue(ctx, db.Outbox{EventID: 1, Kind: db.OUTBOX_KIND_FRESH, Transport: "later", Receiver: "receiver", Revision: "rev-1", NextRetryAt: time.Now().Add(time.Hour)})
+
+	names, err := dueTransports(ctx, outboxRepo, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"telegram", "whatsapp", "whatsapp/promo"}, names)
+
+	names, err = dueTransports(ctx, outboxRepo, "whatsapp")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"whatsapp", "whatsapp/promo"}, names)
+}
+
+func TestScheduledNotifyKinds(t *testing.T) {
+	keys := []string{"SCHEDULE_NOTIFY_DIGEST", "NEW_EVENTS_CHANNEL_JID", "MONTHLY_CHANNEL_JID", "NOTIFY_STATUS_DESTINATIONS", "NOTIFY_DIGEST_DESTINATIONS"}
+	reset := func() {
+		for _, key := range keys {
+			viper.Set(key, "")
+		}
+	}
+	reset()
+	t.Cleanup(reset)
+
+	t.Run("skip kinds without destinations", func(t *testing.T) {
+		viper.Set("NEW_EVENTS_CHANNEL_JID", "123@newsletter")
+
+		assert.Equal(t, []string{db.OUTBOX_KIND_FRESH}, scheduledNotifyKinds())
+	})
+
+	t.Run("include the configured digest", func(t *testing.T) {
+		viper.Set("SCHEDULE_NOTIFY_DIGEST", "0 10 * * 1")
+		viper.Set("NOTIFY_STATUS_DESTINATIONS", "telegram:@zollhaus")
+		viper.Set("NOTIFY_DIGEST_DESTINATIONS", "telegram:@zollhaus")
+
+		assert.Equal(t, []string{db.OUTBOX_KIND_FRESH, db.OUTBOX_KIND_STATUS, NOTIFY_DIGEST}, scheduledNotifyKinds())
+	})
+}
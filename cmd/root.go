@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/apfelfrisch/zh-notify/internal/db"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var rootCmd = &cobra.Command{
@@ -16,11 +18,26 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().String("db", db.DEFAULT_PATH, "Path of the event database, defaults to DB_PATH")
+	rootCmd.PersistentFlags().String("whatsapp-db", "", "Path of the whatsapp device store, defaults to WHATSAPP_DB_PATH or the event database")
+
+	viper.BindPFlag("DB_PATH", rootCmd.PersistentFlags().Lookup("db"))
+	viper.BindPFlag("WHATSAPP_DB_PATH", rootCmd.PersistentFlags().Lookup("whatsapp-db"))
+}
+
 func Execute() {
+	rootCmd.AddCommand(accountsCmd)
 	rootCmd.AddCommand(crawlEventsCmd)
+	rootCmd.AddCommand(eventCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(historyCmd)
 	rootCmd.AddCommand(linkAccountCmd)
+	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(notifyCmd)
 	rootCmd.AddCommand(updateMetadataCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(runCmd)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/apfelfrisch/zh-notify/internal"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Default schedules, each can be changed with the SCHEDULE_* setting of the
+// same name. SCHEDULE_<NAME>=off disables the job.
+var defaultSchedules = map[string]string{
+	"CRAWL":           "0 */6 * * *",
+	"META":            "15 */6 * * *",
+	"NOTIFY_FRESH":    "0 11 * * *",
+	"NOTIFY_UPCOMING": "0 11 16 * *",
+	"NOTIFY_STATUS":   "30 */6 * * *",
+	"NOTIFY_RETRY":    "*/10 * * * *",
+	// Off unless configured, e.g. "0 10 * * 1" for a weekly digest
+	"NOTIFY_DIGEST": "",
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run crawl, meta and notify on a schedule until stopped",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		database, err := openDatabase(ctx)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		repo := database.eventRepo()
+		scheduler := internal.NewScheduler()
+
+		if err := scheduleJob(scheduler, "CRAWL", func(ctx context.Context) error {
+			return crawl(ctx, repo, database.crawlRunRepo())
+		}); err != nil {
+			return err
+		}
+
+		if schedule("META") != "" {
+			collector, err := newSyncCollector(database.lookupCache(false))
+			if err != nil {
+				return err
+			}
+
+			if err := scheduleJob(scheduler, "META", func(ctx context.Context) error {
+				return updateMetadata(ctx, repo, collector, false)
+			}); err != nil {
+				return err
+			}
+		}
+
+		if kinds := scheduledNotifyKinds(); len(kinds) > 0 || schedule("NOTIFY_RETRY") != "" {
+			destinationsByKind, err := notifyDestinations(kinds, "")
+			if err != nil {
+				return err
+			}
+
+			// The retry job also delivers messages queued by other processes,
+			// like a digest sent with notify
+			names := transportNames(destinationsByKind)
+			if schedule("NOTIFY_RETRY") != "" {
+				due, err := dueTransports(ctx, database.outboxRepo(), "")
+				if err != nil {
+					return err
+				}
+
+				names = lo.Uniq(append(names, due...))
+				sort.Strings(names)
+			}
+
+			templates, err := newTemplates()
+			if err != nil {
+				return err
+			}
+
+			// One connection for all runs, whatsmeow reconnects on its own
+			drivers, err := newDrivers(ctx, database.sqliteConn(), names)
+			if err != nil {
+				return err
+			}
+
+			notificator := internal.NewNotificator(repo, database.outboxRepo(), drivers, templates)
+			defer notificator.Close()
+
+			for _, kind := range kinds {
+				job := func(ctx context.Context) error {
+					return notify(ctx, notificator, kind, destinationsByKind[kind], false)
+				}
+				if kind == NOTIFY_DIGEST {
+					job = func(ctx context.Context) error {
+						return notifyDigest(ctx, notificator, destinationsByKind[kind], digestDays(), false)
+					}
+				}
+
+				if err := scheduleJob(scheduler, "NOTIFY_"+strings.ToUpper(kind), job); err != nil {
+					return err
+				}
+			}
+
+			if err := scheduleJob(scheduler, "NOTIFY_RETRY", notificator.ProcessOutbox); err != nil {
+				return err
+			}
+		}
+
+		log.Println("Scheduler started")
+
+		scheduler.Run(ctx)
+
+		log.Println("Scheduler stopped")
+
+		return nil
+	},
+}
+
+func schedule(name string) string {
+	key := "SCHEDULE_" + name
+
+	if !viper.IsSet(key) {
+		return defaultSchedules[name]
+	}
+
+	if spec := viper.GetString(key); spec != "off" {
+		return spec
+	}
+
+	return ""
+}
+
+// scheduledNotifyKinds lists the notification kinds of the enabled jobs,
+// kinds without configured destinations are skipped.
+func scheduledNotifyKinds() []string {
+	return lo.Filter(append(slices.Clone(notifyKinds), NOTIFY_DIGEST), func(kind string, _ int) bool {
+		name := "NOTIFY_" + strings.ToUpper(kind)
+		if schedule(name) == "" {
+			return false
+		}
+
+		if !hasDestinations(kind) {
+			log.Printf("Skipped %v, no destinations configured", name)
+			return false
+		}
+
+		return true
+	})
+}
+
+func scheduleJob(scheduler *internal.Scheduler, name string, job internal.Job) error {
+	spec := schedule(name)
+	if spec == "" {
+		return nil
+	}
+
+	if err := scheduler.Add(name, spec, job); err != nil {
+		return fmt.Errorf("Invalid SCHEDULE_%v [%v]: %w", name, spec, err)
+	}
+
+	log.Printf("Scheduled %v at [%v]", name, spec)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const DEFAULT_HTTP_ADDR = ":8080"
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the events as JSON and ICS over HTTP",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		if addr == "" {
+			addr = viper.GetString("HTTP_ADDR")
+		}
+		if addr == "" {
+			addr = DEFAULT_HTTP_ADDR
+		}
+
+		repo, err := newEventRepo(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		return serve(ctx, addr, internal.NewServer(repo))
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "", "Listen address, defaults to HTTP_ADDR or "+DEFAULT_HTTP_ADDR)
+}
+
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Println("Listening on " + addr)
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
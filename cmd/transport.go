@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+	"github.com/apfelfrisch/zh-notify/internal/transport/telegram"
+	"github.com/apfelfrisch/zh-notify/internal/transport/whatsapp"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+const NOTIFY_DIGEST = "digest"
+
+var notifyKinds = []string{db.OUTBOX_KIND_FRESH, db.OUTBOX_KIND_UPCOMING, db.OUTBOX_KIND_STATUS}
+
+// The legacy receivers of each notification kind, per transport
+var channelKeys = map[string]map[string]string{
+	whatsapp.NAME: {
+		db.OUTBOX_KIND_FRESH:    "NEW_EVENTS_CHANNEL_JID",
+		db.OUTBOX_KIND_UPCOMING: "MONTHLY_CHANNEL_JID",
+		NOTIFY_DIGEST:           "MONTHLY_CHANNEL_JID",
+	},
+	telegram.NAME: {
+		db.OUTBOX_KIND_FRESH:    "TELEGRAM_NEW_EVENTS_CHAT_ID",
+		db.OUTBOX_KIND_UPCOMING: "TELEGRAM_MONTHLY_CHAT_ID",
+		NOTIFY_DIGEST:           "TELEGRAM_MONTHLY_CHAT_ID",
+	},
+}
+
+func transportName(flag string) string {
+	if flag != "" {
+		return flag
+	}
+
+	if name := viper.GetString("TRANSPORT"); name != "" {
+		return name
+	}
+
+	return whatsapp.NAME
+}
+
+// newDriver connects the transport, "whatsapp/<profile>" sends with the
+// account linked under that profile. Plain "whatsapp" uses SENDER_JID, or the
+// default profile without it.
+func newDriver(ctx context.Context, conn *sql.DB, name string) (transport.Driver, error) {
+	transportName, profile := transport.SplitProfile(name)
+
+	switch transportName {
+	case whatsapp.NAME:
+		store, err := whatsappStoreConn(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		if profile != "" {
+			return whatsapp.ConnectProfile(ctx, store, profile)
+		}
+
+		if senderJid := viper.GetString("SENDER_JID"); senderJid != "" {
+			return whatsapp.Connect(ctx, store, senderJid)
+		}
+
+		return whatsapp.ConnectProfile(ctx, store, whatsapp.DEFAULT_PROFILE)
+	case telegram.NAME:
+		if profile != "" {
+			return nil, fmt.Errorf("Sender profiles are not supported by [%v]", transportName)
+		}
+
+		token := viper.GetString("TELEGRAM_BOT_TOKEN")
+		if token == "" {
+			return nil, errors.New("Could not read TELEGRAM_BOT_TOKEN from env")
+		}
+
+		if baseUrl := viper.GetString("TELEGRAM_BASE_URL"); baseUrl != "" {
+			return telegram.NewWithBaseUrl(token, baseUrl), nil
+		}
+
+		return telegram.New(token), nil
+	}
+
+	return nil, fmt.Errorf("Unknown transport [%v]", name)
+}
+
+// destinations reads the receivers of a notification kind from
+// NOTIFY_<KIND>_DESTINATIONS, e.g. "whatsapp:123@newsletter,telegram:@zollhaus".
+// Without that setting the channel of the legacy setting of the transport is
+// used, kinds without a legacy setting have no destinations. The transport
+// flag narrows the list down to a single transport.
+func destinations(kind string, transportFlag string) ([]transport.Destination, error) {
+	key := "NOTIFY_" + strings.ToUpper(kind) + "_DESTINATIONS"
+
+	value := viper.GetString(key)
+	if value == "" && kind == db.OUTBOX_KIND_STATUS {
+		return nil, nil
+	}
+
+	if value == "" {
+		destination, err := destination(transportName(transportFlag), kind)
+		if err != nil {
+			return nil, err
+		}
+
+		return []transport.Destination{destination}, nil
+	}
+
+	destinations, err := transport.ParseDestinations(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %v: %w", key, err)
+	}
+
+	if transportFlag != "" {
+		destinations = lo.Filter(destinations, func(destination transport.Destination, _ int) bool {
+			transportName, _ := transport.SplitProfile(destination.Transport)
+			return destination.Transport == transportFlag || transportName == transportFlag
+		})
+	}
+
+	if len(destinations) == 0 {
+		return nil, fmt.Errorf("No destinations for [%v] in %v", transportFlag, key)
+	}
+
+	return destinations, nil
+}
+
+// hasDestinations reports whether receivers of the notification kind are
+// configured, in NOTIFY_<KIND>_DESTINATIONS or the legacy setting.
+func hasDestinations(kind string) bool {
+	if viper.GetString("NOTIFY_"+strings.ToUpper(kind)+"_DESTINATIONS") != "" {
+		return true
+	}
+
+	key, ok := channelKeys[transportName("")][kind]
+
+	return ok && viper.GetString(key) != ""
+}
+
+// notifyDestinations resolves the destinations of every given kind.
+func notifyDestinations(kinds []string, transportFlag string) (map[string][]transport.Destination, error) {
+	destinationsByKind := map[string][]transport.Destination{}
+
+	for _, kind := range kinds {
+		kindDestinations, err := destinations(kind, transportFlag)
+		if err != nil {
+			return nil, err
+		}
+
+		destinationsByKind[kind] = kindDestinations
+	}
+
+	return destinationsByKind, nil
+}
+
+// transportNames lists every transport used by the destinations once.
+func transportNames(destinationsByKind map[string][]transport.Destination) []string {
+	var names []string
+
+	for _, kindDestinations := range destinationsByKind {
+		for _, destination := range kindDestinations {
+			names = append(names, destination.Transport)
+		}
+	}
+
+	names = lo.Uniq(names)
+	sort.Strings(names)
+
+	return names
+}
+
+func newDrivers(ctx context.Context, conn *sql.DB, transportNames []string) (map[string]transport.Driver, error) {
+	drivers := map[string]transport.Driver{}
+
+	for _, name := range transportNames {
+		driver, err := newDriver(ctx, conn, name)
+		if err != nil {
+			return nil, err
+		}
+
+		drivers[name] = driver
+	}
+
+	return drivers, nil
+}
+
+func destination(transportName string, kind string) (transport.Destination, error) {
+	key, ok := channelKeys[transportName][kind]
+	if !ok {
+		return transport.Destination{}, fmt.Errorf("Unknown transport [%v]", transportName)
+	}
+
+	receiver := viper.GetString(key)
+	if receiver == "" {
+		return transport.Destination{}, fmt.Errorf("Could not read %v from env", key)
+	}
+
+	return transport.Destination{Transport: transportName, Receiver: receiver}, nil
+}
@@ -1,25 +1,53 @@
 package internal
 
 import (
+	"fmt"
+
 	"github.com/apfelfrisch/zh-notify/internal/collect"
-	"github.com/apfelfrisch/zh-notify/internal/collect/openai"
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/collect/rules"
 	"github.com/apfelfrisch/zh-notify/internal/collect/spotify"
 	"github.com/apfelfrisch/zh-notify/internal/db"
 )
 
-const URL = "https://www.zollhaus-leer.com/veranstaltungen/"
+func CollectNewEvents(sourceNames []string) ([]collect.Event, error) {
+	var events []collect.Event
+
+	for _, name := range sourceNames {
+		source, err := collect.GetSource(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		crawled, err := source.Crawl()
+
+		if err != nil {
+			return nil, fmt.Errorf("Could not crawl [%v]: %w", name, err)
+		}
+
+		events = append(events, crawled...)
+	}
 
-func CollectNewEvents() ([]collect.Event, error) {
-	return collect.CrawlEvents(URL)
+	return events, nil
 }
 
-func NewSyncEventCollector(openAiToken, spotifyId, sporitySecret string) *SyncCollector {
-	return &SyncCollector{
-		service: &syncService{
-			OpenAi:  openai.New(openAiToken),
-			Spotify: spotify.New(spotifyId, sporitySecret),
-		},
+// NewSyncEventCollector fills the metadata with the rules first. Only titles
+// no rule knows are sent to the provider, which may be nil to rely on the
+// rules alone. Without a spotify id the artist links are skipped. Both share
+// the lookup cache, if it is not nil.
+func NewSyncEventCollector(ruleSet *rules.Service, provider llm.Provider, cache db.LookupCacheRepository, spotifyId, sporitySecret string) *SyncCollector {
+	service := &syncService{Rules: ruleSet}
+
+	if provider != nil {
+		service.Llm = llm.New(provider, cache)
+	}
+
+	if spotifyId != "" {
+		service.Spotify = spotify.New(spotifyId, sporitySecret, cache)
 	}
+
+	return &SyncCollector{service: service}
 }
 
 type SyncCollector struct {
@@ -30,26 +58,28 @@ func (sc *SyncCollector) Init() error {
 	return sc.service.Init()
 }
 
+// Sync fills the missing metadata of the event, fields set by hand are
+// left as they are.
 func (sc *SyncCollector) Sync(event *db.Event) error {
-	if !event.Artist.Valid {
+	if !event.Artist.Valid && !event.Locked(db.FIELD_ARTIST) {
 		if err := sc.service.SetArtist(event); err != nil {
 			return err
 		}
 	}
 
-	if !event.Category.Valid {
+	if !event.Category.Valid && !event.Locked(db.FIELD_CATEGORY) {
 		if err := sc.service.SetCategory(event); err != nil {
 			return err
 		}
 	}
 
-	if !event.ArtistUrl.Valid {
+	if !event.ArtistUrl.Valid && !event.Locked(db.FIELD_ARTIST_URL) {
 		if err := sc.service.SetArtistUrl(event); err != nil {
 			return err
 		}
 	}
 
-	if !event.ArtistImgUrl.Valid {
+	if !event.ArtistImgUrl.Valid && !event.Locked(db.FIELD_ARTIST_IMG_URL) {
 		if err := sc.service.SetArtistImgUrl(event); err != nil {
 			return err
 		}
@@ -59,32 +89,55 @@ func (sc *SyncCollector) Sync(event *db.Event) error {
 }
 
 type syncService struct {
-	OpenAi  *openai.Service
+	Rules   *rules.Service
+	Llm     *llm.Service
 	Spotify *spotify.Service
 }
 
 func (md *syncService) Init() error {
-	if err := md.OpenAi.Init(); err != nil {
-		return err
-	}
-	if err := md.Spotify.Init(); err != nil {
-		return err
+	if md.Spotify == nil {
+		return nil
 	}
-	return nil
+
+	return md.Spotify.Init()
 }
 
 func (md *syncService) SetArtist(event *db.Event) error {
-	return md.OpenAi.SetArtist(event)
+	if err := md.Rules.SetArtist(event); err != nil {
+		return err
+	}
+
+	if md.Llm == nil || md.Rules.Knows(event) {
+		return nil
+	}
+
+	return md.Llm.SetArtist(event)
 }
 
 func (md *syncService) SetCategory(event *db.Event) error {
-	return md.OpenAi.SetCategory(event)
+	if err := md.Rules.SetCategory(event); err != nil {
+		return err
+	}
+
+	if md.Llm == nil || md.Rules.Knows(event) {
+		return nil
+	}
+
+	return md.Llm.SetCategory(event)
 }
 
 func (md *syncService) SetArtistUrl(event *db.Event) error {
+	if md.Spotify == nil {
+		return nil
+	}
+
 	return md.Spotify.SetArtistUrl(event)
 }
 
 func (md *syncService) SetArtistImgUrl(event *db.Event) error {
+	if md.Spotify == nil {
+		return nil
+	}
+
 	return md.Spotify.SetArtistImgUrl(event)
 }
@@ -0,0 +1,214 @@
+package collect
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed "sources"
+var definitionFiles embed.FS
+
+// Definition describes where a venue lists its events and which selectors
+// extract them, so a markup change only needs a config edit.
+type Definition struct {
+	Name      string    `yaml:"name"`
+	Url       string    `yaml:"url"`
+	Selectors Selectors `yaml:"selectors"`
+}
+
+type Selectors struct {
+	List   string        `yaml:"list"`
+	Title  string        `yaml:"title"`
+	Link   string        `yaml:"link"`
+	Date   DateSelector  `yaml:"date"`
+	Detail string        `yaml:"detail"`
+	Image  ImageSelector `yaml:"image"`
+	Place  PlaceSelector `yaml:"place"`
+	Status string        `yaml:"status"`
+	Start  TimeSelector  `yaml:"start"`
+	Doors  TimeSelector  `yaml:"doors"`
+}
+
+type DateSelector struct {
+	Selector string `yaml:"selector"`
+	// Pattern finds the date in the selected text. If it has a group named
+	// "date", only that group is parsed.
+	Pattern     string `yaml:"pattern"`
+	Layout      string `yaml:"layout"`
+	DefaultTime string `yaml:"default_time"`
+}
+
+// TimeSelector finds a time of day on the single event page.
+type TimeSelector struct {
+	Selector string `yaml:"selector"`
+	// Pattern finds the time in the selected text. If it has a group named
+	// "time", only that group is parsed.
+	Pattern string `yaml:"pattern"`
+	// Layout defaults to 15:04
+	Layout string `yaml:"layout"`
+}
+
+type ImageSelector struct {
+	Selector string `yaml:"selector"`
+	// Attributes are tried in order, the first non empty one wins.
+	Attributes []string `yaml:"attributes"`
+}
+
+type PlaceSelector struct {
+	Item string `yaml:"item"`
+	// Icon and IconClass narrow the items down to the one marked with the
+	// given icon. Without them, the first item is used.
+	Icon      string `yaml:"icon"`
+	IconClass string `yaml:"icon_class"`
+	Text      string `yaml:"text"`
+}
+
+func ParseDefinition(data []byte) (Definition, error) {
+	var def Definition
+
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return def, err
+	}
+
+	return def, def.Validate()
+}
+
+func (def Definition) Validate() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"name", def.Name},
+		{"url", def.Url},
+		{"selectors.list", def.Selectors.List},
+		{"selectors.title", def.Selectors.Title},
+		{"selectors.link", def.Selectors.Link},
+		{"selectors.date.selector", def.Selectors.Date.Selector},
+		{"selectors.date.pattern", def.Selectors.Date.Pattern},
+		{"selectors.date.layout", def.Selectors.Date.Layout},
+	}
+
+	for _, field := range required {
+		if field.value == "" {
+			return fmt.Errorf("Source definition [%v] is missing [%v]", def.Name, field.name)
+		}
+	}
+
+	if def.Selectors.Place.Item != "" && def.Selectors.Place.Text == "" {
+		return fmt.Errorf("Source definition [%v] is missing [selectors.place.text]", def.Name)
+	}
+
+	if _, err := regexp.Compile(def.Selectors.Date.Pattern); err != nil {
+		return fmt.Errorf("Source definition [%v] has an invalid date pattern: %w", def.Name, err)
+	}
+
+	if _, err := def.Selectors.Date.defaultTime(); err != nil {
+		return fmt.Errorf("Source definition [%v] has an invalid default time: %w", def.Name, err)
+	}
+
+	for name, ts := range map[string]TimeSelector{"start": def.Selectors.Start, "doors": def.Selectors.Doors} {
+		if ts.Selector == "" {
+			continue
+		}
+
+		if ts.Pattern == "" {
+			return fmt.Errorf("Source definition [%v] is missing [selectors.%v.pattern]", def.Name, name)
+		}
+
+		if _, err := regexp.Compile(ts.Pattern); err != nil {
+			return fmt.Errorf("Source definition [%v] has an invalid %v pattern: %w", def.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (ds DateSelector) defaultTime() (time.Duration, error) {
+	if ds.DefaultTime == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("15:04", ds.DefaultTime)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (ts TimeSelector) layout() string {
+	if ts.Layout == "" {
+		return "15:04"
+	}
+
+	return ts.Layout
+}
+
+// LoadDefinitions registers every *.yaml source definition found at path,
+// which may be a single file or a directory.
+func LoadDefinitions(path string) error {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return err
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.yaml")); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		def, err := ParseDefinition(data)
+
+		if err != nil {
+			return fmt.Errorf("%v: %w", file, err)
+		}
+
+		RegisterSource(HtmlSource{def})
+	}
+
+	return nil
+}
+
+func registerEmbeddedDefinitions() error {
+	entries, err := definitionFiles.ReadDir("sources")
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		data, err := definitionFiles.ReadFile("sources/" + entry.Name())
+
+		if err != nil {
+			return err
+		}
+
+		def, err := ParseDefinition(data)
+
+		if err != nil {
+			return fmt.Errorf("%v: %w", entry.Name(), err)
+		}
+
+		RegisterSource(HtmlSource{def})
+	}
+
+	return nil
+}
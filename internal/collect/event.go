@@ -30,6 +30,10 @@ type Event struct {
 	Status       string
 	Link         string
 	ArtistImgUrl string
+	Source       string
+	// StartsAt and DoorsAt are zero, if the source doesn't publish them
+	StartsAt time.Time
+	DoorsAt  time.Time
 }
 
 func (pe Event) ToDbEvent(dbEvent db.Event) db.Event {
@@ -40,6 +44,9 @@ func (pe Event) ToDbEvent(dbEvent db.Event) db.Event {
 		dbEvent.Status = strings.TrimSpace(pe.Status)
 		dbEvent.Link = strings.TrimSpace(pe.Link)
 		dbEvent.ArtistImgUrl = sql.NullString{String: strings.TrimSpace(pe.ArtistImgUrl), Valid: true}
+		dbEvent.Source = pe.Source
+		dbEvent.StartsAt = nullTime(pe.StartsAt)
+		dbEvent.DoorsAt = nullTime(pe.DoorsAt)
 
 		return dbEvent
 	}
@@ -67,44 +74,72 @@ func (pe Event) ToDbEvent(dbEvent db.Event) db.Event {
 	if strings.TrimSpace(pe.Link) != "" {
 		dbEvent.Link = strings.TrimSpace(pe.Link)
 	}
-	if strings.TrimSpace(pe.ArtistImgUrl) != "" {
+	// An image set by hand wins over the one of the listing
+	if strings.TrimSpace(pe.ArtistImgUrl) != "" && !dbEvent.Locked(db.FIELD_ARTIST_IMG_URL) {
 		dbEvent.ArtistImgUrl = sql.NullString{String: strings.TrimSpace(pe.ArtistImgUrl), Valid: true}
 	}
+	if pe.Source != "" {
+		dbEvent.Source = pe.Source
+	}
+	if !pe.StartsAt.IsZero() {
+		dbEvent.StartsAt = nullTime(pe.StartsAt)
+	}
+	if !pe.DoorsAt.IsZero() {
+		dbEvent.DoorsAt = nullTime(pe.DoorsAt)
+	}
 
 	return dbEvent
 }
 
-func CrawlEvents(url string) ([]Event, error) {
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: !value.IsZero()}
+}
+
+func CrawlEvents(def Definition) ([]Event, error) {
 	var waitGroup sync.WaitGroup
 
 	var events []Event
 
+	sel := def.Selectors
+
+	datePattern, err := regexp.Compile(sel.Date.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultTime, err := sel.Date.defaultTime()
+	if err != nil {
+		return nil, err
+	}
+
 	c := colly.NewCollector()
 
 	c.OnRequest(func(r *colly.Request) {
 		waitGroup.Add(1)
 	})
 
-	c.OnHTML(".elementor-6082", func(e *colly.HTMLElement) {
+	c.OnHTML(sel.List, func(e *colly.HTMLElement) {
 		event := Event{}
 
-		e.ForEachWithBreak("h3.elementor-heading-title", func(i int, e *colly.HTMLElement) bool {
+		e.ForEachWithBreak(sel.Title, func(i int, e *colly.HTMLElement) bool {
 			event.Name = e.Text
 			return false
 		})
 
 		// Find the date string in the heading
-		e.ForEachWithBreak(".elementor-heading-title", func(i int, el *colly.HTMLElement) bool {
+		e.ForEachWithBreak(sel.Date.Selector, func(i int, el *colly.HTMLElement) bool {
 			text := strings.TrimSpace(el.Text)
 
-			re := regexp.MustCompile(`(?m)^(Mo|Di|Mi|Do|Fr|Sa|So)\., \d{2}\.\d{2}\.\d{4}$`)
-			matches := re.FindAllString(text, -1)
+			for _, match := range datePattern.FindAllStringSubmatch(text, -1) {
+				dateString := match[0]
+				if group := datePattern.SubexpIndex("date"); group > 0 {
+					dateString = match[group]
+				}
 
-			for _, match := range matches {
-				parsedDate, err := time.ParseInLocation("02.01.2006", match[5:], time.Local)
+				parsedDate, err := time.ParseInLocation(sel.Date.Layout, dateString, time.Local)
 
 				if err == nil {
-					event.Date = parsedDate.Add(time.Hour * 6)
+					event.Date = parsedDate.Add(defaultTime)
 					return false
 				}
 
@@ -112,9 +147,9 @@ func CrawlEvents(url string) ([]Event, error) {
 			return true
 		})
 
-		e.ForEachWithBreak("a[href]", func(i int, e *colly.HTMLElement) bool {
-			event.Link = e.Attr("href")
-			if event.Link != "" {
+		e.ForEachWithBreak(sel.Link, func(i int, e *colly.HTMLElement) bool {
+			event.Link = e.Request.AbsoluteURL(e.Attr("href"))
+			if event.Link != "" && sel.Detail != "" {
 				waitGroup.Add(1)
 				go func(link string) {
 					defer waitGroup.Done()
@@ -130,50 +165,119 @@ func CrawlEvents(url string) ([]Event, error) {
 		events = append(events, event)
 	})
 
-	c.OnHTML("body.single-event", func(e *colly.HTMLElement) {
-		for i := range events {
-			if events[i].Link != e.Request.URL.String() {
-				continue
-			}
+	// Detail pages are crawled concurrently with the listing, they are only
+	// merged into the events once every page is done
+	var detailsMutex sync.Mutex
+	details := map[string]*colly.HTMLElement{}
 
-			e.ForEachWithBreak("img.attachment-large", func(_ int, el *colly.HTMLElement) bool {
-				events[i].ArtistImgUrl = el.Attr("data-lazy-src")
-				if events[i].ArtistImgUrl == "" {
-					events[i].ArtistImgUrl = el.Attr("src")
-				}
-				return false
-			})
+	if sel.Detail != "" {
+		c.OnHTML(sel.Detail, func(e *colly.HTMLElement) {
+			detailsMutex.Lock()
+			defer detailsMutex.Unlock()
 
-			e.ForEachWithBreak("li.elementor-icon-list-item", func(_ int, li *colly.HTMLElement) bool {
-				hasMapPin := false
-				li.ForEach("span.elementor-icon-list-icon i", func(_ int, icon *colly.HTMLElement) {
-					if icon.Attr("class") == "fad fa-map-pin" {
-						hasMapPin = true
-					}
-				})
-				if hasMapPin {
-					events[i].Place = li.ChildText("span.elementor-icon-list-text")
-					return false
-				}
-				return true
-			})
-
-			e.ForEachWithBreak("div.elementor-element-5bb6689 .elementor-button-text", func(_ int, btn *colly.HTMLElement) bool {
-				events[i].Status = html.UnescapeString(btn.Text)
-				return false
-			})
-		}
-	})
+			details[e.Request.URL.String()] = e
+		})
+	}
 
 	c.OnScraped(func(r *colly.Response) {
 		waitGroup.Done()
 	})
 
-	if err := c.Visit(url); err != nil {
+	if err := c.Visit(def.Url); err != nil {
 		return nil, err
 	}
 
 	waitGroup.Wait()
 
+	for i := range events {
+		if detail, ok := details[events[i].Link]; ok {
+			crawlEventDetails(detail, sel, &events[i])
+		}
+	}
+
 	return events, nil
 }
+
+func crawlEventDetails(e *colly.HTMLElement, sel Selectors, event *Event) {
+	if sel.Image.Selector != "" {
+		attributes := sel.Image.Attributes
+		if len(attributes) == 0 {
+			attributes = []string{"src"}
+		}
+
+		e.ForEachWithBreak(sel.Image.Selector, func(_ int, el *colly.HTMLElement) bool {
+			for _, attr := range attributes {
+				if src := el.Attr(attr); src != "" {
+					event.ArtistImgUrl = e.Request.AbsoluteURL(src)
+					break
+				}
+			}
+			return false
+		})
+	}
+
+	if sel.Place.Item != "" {
+		e.ForEachWithBreak(sel.Place.Item, func(_ int, li *colly.HTMLElement) bool {
+			hasIcon := sel.Place.Icon == ""
+			if !hasIcon {
+				li.ForEach(sel.Place.Icon, func(_ int, icon *colly.HTMLElement) {
+					if icon.Attr("class") == sel.Place.IconClass {
+						hasIcon = true
+					}
+				})
+			}
+			if hasIcon {
+				event.Place = li.ChildText(sel.Place.Text)
+				return false
+			}
+			return true
+		})
+	}
+
+	if sel.Status != "" {
+		e.ForEachWithBreak(sel.Status, func(_ int, btn *colly.HTMLElement) bool {
+			event.Status = html.UnescapeString(btn.Text)
+			return false
+		})
+	}
+
+	event.StartsAt = crawlTime(e, sel.Start, event.Date)
+	event.DoorsAt = crawlTime(e, sel.Doors, event.Date)
+}
+
+// crawlTime finds the time of day on the page and puts it on the day of the
+// event. It returns the zero time, if there is none.
+func crawlTime(e *colly.HTMLElement, ts TimeSelector, day time.Time) time.Time {
+	if ts.Selector == "" || day.IsZero() {
+		return time.Time{}
+	}
+
+	pattern, err := regexp.Compile(ts.Pattern)
+	if err != nil {
+		return time.Time{}
+	}
+
+	var result time.Time
+
+	e.ForEachWithBreak(ts.Selector, func(_ int, el *colly.HTMLElement) bool {
+		match := pattern.FindStringSubmatch(strings.TrimSpace(el.Text))
+		if match == nil {
+			return true
+		}
+
+		timeString := match[0]
+		if group := pattern.SubexpIndex("time"); group > 0 {
+			timeString = match[group]
+		}
+
+		clock, err := time.Parse(ts.layout(), timeString)
+		if err != nil {
+			return true
+		}
+
+		result = time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, day.Location())
+		return false
+	})
+
+	return result
+}
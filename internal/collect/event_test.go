@@ -19,6 +19,7 @@ func TestMapCollectedEventToDbvents(t *testing.T) {
 		Status:       "cStatus",
 		Link:         "cLink",
 		ArtistImgUrl: "cArtistUrl",
+		Source:       "cSource",
 	}
 
 	var tests = []struct {
@@ -38,6 +39,7 @@ func TestMapCollectedEventToDbvents(t *testing.T) {
 				Link:         tmplEvent.Link,
 				Date:         tmplEvent.Date,
 				ArtistImgUrl: sql.NullString{String: tmplEvent.ArtistImgUrl, Valid: true},
+				Source:       tmplEvent.Source,
 			},
 		},
 		{
@@ -67,6 +69,12 @@ func TestMapCollectedEventToDbvents(t *testing.T) {
 			db.Event{ID: 1, Name: "N", Place: "P", Status: "S", Link: "L", ArtistImgUrl: sql.NullString{String: "URL", Valid: true}},
 			db.Event{ID: 1, Name: "N", Place: "P", Status: "S", Link: "L", ArtistImgUrl: sql.NullString{String: "URL", Valid: true}},
 		},
+		{
+			"keep a locked artist image",
+			Event{ArtistImgUrl: "cArtistUrl"},
+			db.Event{ID: 1, ArtistImgUrl: sql.NullString{String: "URL", Valid: true}, LockedFields: "artist_img_url"},
+			db.Event{ID: 1, ArtistImgUrl: sql.NullString{String: "URL", Valid: true}, LockedFields: "artist_img_url"},
+		},
 	}
 
 	for _, test := range tests {
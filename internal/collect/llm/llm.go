@@ -0,0 +1,199 @@
+// Package llm fills the artist and category of events with a language model.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+)
+
+// A malformed response is requested again this many times at most
+const MAX_ATTEMPTS = 3
+
+const CATEGORY_UNKNOWN = "unknown"
+
+// The metadata of a title is kept in the lookup cache for this long
+const CACHE_KIND = "llm"
+const CACHE_TTL = 90 * 24 * time.Hour
+
+// CATEGORIES are the only categories a provider may answer with.
+var CATEGORIES = []string{"concert", "reading", "theatre", "comedy", "party", CATEGORY_UNKNOWN}
+
+// Metadata is what a provider extracts from the title of an event.
+type Metadata struct {
+	Artist   string `json:"artist"`
+	Category string `json:"category"`
+}
+
+// Provider extracts the metadata from the title of an event, e.g. with the
+// OpenAI API or a local model behind an OpenAI compatible server. Responses
+// that can't be parsed are reported as InvalidResponseError.
+type Provider interface {
+	Extract(ctx context.Context, title string) (Metadata, error)
+}
+
+// InvalidResponseError is returned for responses that don't match the
+// schema, Raw holds the response as it was received.
+type InvalidResponseError struct {
+	Raw string
+	Err error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("Invalid response: %v", e.Err)
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads a response strictly: unknown fields and categories outside
+// of CATEGORIES are rejected.
+func Parse(raw string) (Metadata, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.DisallowUnknownFields()
+
+	var fields struct {
+		Artist   *string `json:"artist"`
+		Category *string `json:"category"`
+	}
+
+	if err := decoder.Decode(&fields); err != nil {
+		return Metadata{}, &InvalidResponseError{Raw: raw, Err: err}
+	}
+
+	if decoder.More() {
+		return Metadata{}, &InvalidResponseError{Raw: raw, Err: errors.New("Trailing data after the object")}
+	}
+
+	if fields.Artist == nil || fields.Category == nil {
+		return Metadata{}, &InvalidResponseError{Raw: raw, Err: errors.New("Missing artist or category")}
+	}
+
+	metaData := Metadata{
+		Artist:   strings.TrimSpace(*fields.Artist),
+		Category: strings.TrimSpace(*fields.Category),
+	}
+
+	if !lo.Contains(CATEGORIES, metaData.Category) {
+		return Metadata{}, &InvalidResponseError{Raw: raw, Err: fmt.Errorf("Unknown category [%v]", metaData.Category)}
+	}
+
+	return metaData, nil
+}
+
+// New creates the service, cache may be nil to always ask the provider.
+func New(provider Provider, cache db.LookupCacheRepository) *Service {
+	return &Service{provider: provider, cache: cache}
+}
+
+type response struct {
+	eventId  int64
+	metaData Metadata
+}
+
+// Service sets the artist and the category of an event with one request to
+// the provider. Invalid responses are requested again up to MAX_ATTEMPTS
+// times, the event is left as it is if none of them is valid. Valid
+// responses are cached by the title of the event.
+type Service struct {
+	provider Provider
+	cache    db.LookupCacheRepository
+	response *response
+}
+
+func (s *Service) SetArtist(event *db.Event) error {
+	if event.Artist.Valid {
+		return nil
+	}
+
+	metaData, err := s.extract(event)
+
+	if err != nil {
+		return err
+	}
+
+	if metaData.Artist != "" {
+		event.Artist = sql.NullString{String: metaData.Artist, Valid: true}
+	}
+
+	return nil
+}
+
+func (s *Service) SetCategory(event *db.Event) error {
+	if event.Category.Valid {
+		return nil
+	}
+
+	metaData, err := s.extract(event)
+
+	if err != nil {
+		return err
+	}
+
+	if metaData.Category != "" {
+		event.Category = sql.NullString{String: metaData.Category, Valid: true}
+	}
+
+	return nil
+}
+
+func (s *Service) extract(event *db.Event) (Metadata, error) {
+	if s.response != nil && s.response.eventId == event.ID {
+		return s.response.metaData, nil
+	}
+
+	ctx := context.Background()
+
+	if s.cache != nil {
+		var metaData Metadata
+
+		found, err := s.cache.Get(ctx, CACHE_KIND, event.Name, &metaData)
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if found {
+			s.response = &response{eventId: event.ID, metaData: metaData}
+
+			return metaData, nil
+		}
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= MAX_ATTEMPTS; attempt++ {
+		var metaData Metadata
+
+		metaData, err = s.provider.Extract(ctx, event.Name)
+
+		var invalid *InvalidResponseError
+		if errors.As(err, &invalid) {
+			continue
+		}
+
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if s.cache != nil {
+			if err := s.cache.Put(ctx, CACHE_KIND, event.Name, metaData, CACHE_TTL); err != nil {
+				return Metadata{}, err
+			}
+		}
+
+		s.response = &response{eventId: event.ID, metaData: metaData}
+
+		return metaData, nil
+	}
+
+	return Metadata{}, err
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider answers with the raw responses in order, the last one is repeated.
+type fakeProvider struct {
+	responses []string
+	titles    []string
+}
+
+func (fp *fakeProvider) Extract(ctx context.Context, title string) (Metadata, error) {
+	fp.titles = append(fp.titles, title)
+
+	raw := fp.responses[min(len(fp.titles), len(fp.responses))-1]
+
+	return Parse(raw)
+}
+
+// fakeCache keeps the entries in memory and ignores the ttl.
+type fakeCache map[string][]byte
+
+func (fc fakeCache) Get(ctx context.Context, kind string, key string, value any) (bool, error) {
+	raw, ok := fc[kind+":"+db.LookupKey(key)]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(raw, value)
+}
+
+func (fc fakeCache) Put(ctx context.Context, kind string, key string, value any, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
+	fc[kind+":"+db.LookupKey(key)] = raw
+
+	return err
+}
+
+func TestService(t *testing.T) {
+	t.Run("set artist and category with one request", func(t *testing.T) {
+		provider := &fakeProvider{responses: []string{`{"artist": "artist-1", "category": "concert"}`}}
+		service := New(provider, nil)
+		event := db.Event{ID: 1, Name: "artist-1 - Tour"}
+
+		assert.Nil(t, service.SetArtist(&event))
+		assert.Nil(t, service.SetCategory(&event))
+
+		assert.Equal(t, "artist-1", event.Artist.String)
+		assert.Equal(t, "concert", event.Category.String)
+		assert.Equal(t, []string{"artist-1 - Tour"}, provider.titles)
+	})
+
+	t.Run("retry invalid responses", func(t *testing.T) {
+		provider := &fakeProvider{responses: []string{`{"artist": "artist-1"`, `{"artist": "artist-1", "category": "concert"}`}}
+		service := New(provider, nil)
+		event := db.Event{ID: 1, Name: "artist-1 - Tour"}
+
+		assert.Nil(t, service.SetCategory(&event))
+
+		assert.Equal(t, "concert", event.Category.String)
+		assert.Len(t, provider.titles, 2)
+	})
+
+	t.Run("reuse cached metadata of the same title", func(t *testing.T) {
+		provider := &fakeProvider{responses: []string{`{"artist": "artist-1", "category": "concert"}`}}
+		service := New(provider, fakeCache{})
+		first := db.Event{ID: 1, Name: "artist-1 - Tour"}
+		second := db.Event{ID: 2, Name: "Artist-1 - tour"}
+
+		assert.Nil(t, service.SetCategory(&first))
+		assert.Nil(t, service.SetCategory(&second))
+
+		assert.Equal(t, "concert", second.Category.String)
+		assert.Len(t, provider.titles, 1)
+	})
+
+	t.Run("give up after the last attempt", func(t *testing.T) {
+		provider := &fakeProvider{responses: []string{`{"artist": "artist-1", "category": "unkown"}`}}
+		service := New(provider, nil)
+		event := db.Event{ID: 1, Name: "artist-1 - Tour"}
+
+		err := service.SetCategory(&event)
+
+		var invalid *InvalidResponseError
+		assert.ErrorAs(t, err, &invalid)
+		assert.Equal(t, `{"artist": "artist-1", "category": "unkown"}`, invalid.Raw)
+		assert.False(t, event.Category.Valid)
+		assert.Len(t, provider.titles, MAX_ATTEMPTS)
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected Metadata
+		valid    bool
+	}{
+		{"valid", `{"artist": " artist-1 ", "category": "comedy"}`, Metadata{Artist: "artist-1", Category: "comedy"}, true},
+		{"empty artist", `{"artist": "", "category": "party"}`, Metadata{Category: "party"}, true},
+		{"unknown category", `{"artist": "artist-1", "category": "unkown"}`, Metadata{}, false},
+		{"missing category", `{"artist": "artist-1"}`, Metadata{}, false},
+		{"unknown field", `{"artist": "artist-1", "category": "party", "genre": "rock"}`, Metadata{}, false},
+		{"markdown fence", "```json\n{\"artist\": \"artist-1\", \"category\": \"party\"}\n```", Metadata{}, false},
+		{"trailing object", `{"artist": "", "category": "party"} {}`, Metadata{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metaData, err := Parse(test.raw)
+
+			assert.Equal(t, test.expected, metaData)
+			if test.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -2,133 +2,107 @@ package openai
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
 
-	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
 
 	sdk "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
+// The structured outputs with a strict json schema need gpt-4o-mini or newer
+const DEFAULT_MODEL = sdk.GPT4oMini
+
 const INIT_PROMT string = `Filter aus der Ankündigung den "Interpreten" und die "Kategorie" der Veranstaltung.
-- Folgende Kategorien stehen zur Verfügung: concert, reading, theatre, comedy, party, unkown.
+- Folgende Kategorien stehen zur Verfügung: concert, reading, theatre, comedy, party, unknown.
 - Der Text zwischen () muss ignoriert werden.
 - Ignoriere "& Band".
-- Umschließe die Antwort nicht mit JSON-Markierungen.
 Antworte im folgendem json format: {"artist": "Interpreten", "category": "Kategorie"}`
 
-func New(apiToken string) *Service {
-	return &Service{
-		client:    sdk.NewClient(apiToken),
-		initPromt: nil,
-		response:  nil,
-	}
-}
-
-type metaData struct {
-	Artist   string `json:"artist"`
-	Category string `json:"category"`
-}
-
-type openaiResp struct {
-	event    db.Event
-	metaData metaData
-}
-
-type Service struct {
-	client    *sdk.Client
-	initPromt *sdk.ChatCompletionMessage
-	response  *openaiResp
-}
-
-func (oai *Service) Init() error {
-	message := sdk.ChatCompletionMessage{
-		Role:    sdk.ChatMessageRoleUser,
-		Content: INIT_PROMT,
-	}
-
-	_, err := oai.client.CreateChatCompletion(
-		context.Background(),
-		sdk.ChatCompletionRequest{
-			Model:    sdk.GPT3Dot5Turbo,
-			Messages: []sdk.ChatCompletionMessage{message},
+// The response has to match this schema, servers that support structured
+// outputs enforce it already
+var responseFormat = &sdk.ChatCompletionResponseFormat{
+	Type: sdk.ChatCompletionResponseFormatTypeJSONSchema,
+	JSONSchema: &sdk.ChatCompletionResponseFormatJSONSchema{
+		Name:   "event_metadata",
+		Strict: true,
+		Schema: &jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"artist":   {Type: jsonschema.String},
+				"category": {Type: jsonschema.String, Enum: llm.CATEGORIES},
+			},
+			Required:             []string{"artist", "category"},
+			AdditionalProperties: false,
 		},
-	)
-
-	oai.initPromt = &message
-
-	return err
+	},
 }
 
-func (oai *Service) SetArtist(event *db.Event) error {
-	if event.Artist.Valid {
-		return nil
-	}
-
-	metaData, err := oai.requestHeadlineParsing(event)
-
-	if err != nil {
-		return err
-	}
-
-	if metaData.Artist != "" {
-		event.Artist = sql.NullString{String: metaData.Artist, Valid: true}
-	}
-
-	return nil
+// Config points the provider to any OpenAI compatible API, e.g. Ollama on
+// "http://localhost:11434/v1" or a llama.cpp server. An empty BaseUrl uses
+// the OpenAI API, an empty Model DEFAULT_MODEL.
+type Config struct {
+	Token   string
+	BaseUrl string
+	Model   string
 }
 
-func (oai *Service) SetCategory(event *db.Event) error {
-	if event.Category.Valid {
-		return nil
+func New(config Config) *Provider {
+	clientConfig := sdk.DefaultConfig(config.Token)
+	if config.BaseUrl != "" {
+		clientConfig.BaseURL = config.BaseUrl
 	}
 
-	metaData, err := oai.requestHeadlineParsing(event)
-
-	if err != nil {
-		return err
+	model := config.Model
+	if model == "" {
+		model = DEFAULT_MODEL
 	}
 
-	if metaData.Category != "" {
-		event.Category = sql.NullString{String: metaData.Category, Valid: true}
+	return &Provider{
+		client: sdk.NewClientWithConfig(clientConfig),
+		model:  model,
 	}
-
-	return nil
 }
 
-func (oai *Service) requestHeadlineParsing(event *db.Event) (metaData, error) {
-	if oai.response != nil && oai.response.event.ID == event.ID {
-		return oai.response.metaData, nil
-	}
+// Provider implements llm.Provider with the chat completions API.
+type Provider struct {
+	client *sdk.Client
+	model  string
+}
 
+func (p *Provider) Extract(ctx context.Context, title string) (llm.Metadata, error) {
 	messages := []sdk.ChatCompletionMessage{
-		*oai.initPromt,
 		{
 			Role:    sdk.ChatMessageRoleUser,
-			Content: event.Name,
+			Content: INIT_PROMT,
+		},
+		{
+			Role:    sdk.ChatMessageRoleUser,
+			Content: title,
 		},
 	}
 
-	resp, err := oai.client.CreateChatCompletion(
-		context.Background(),
+	resp, err := p.client.CreateChatCompletion(
+		ctx,
 		sdk.ChatCompletionRequest{
-			Model:    sdk.GPT3Dot5Turbo,
-			Messages: messages,
+			Model:          p.model,
+			Messages:       messages,
+			ResponseFormat: responseFormat,
 		},
 	)
 
-	var md metaData
-
-	if err != nil || len(resp.Choices) != 1 {
-		return md, err
+	if err != nil {
+		return llm.Metadata{}, err
 	}
 
-	json.Unmarshal([]byte(resp.Choices[0].Message.Content), &md)
+	if len(resp.Choices) != 1 {
+		return llm.Metadata{}, &llm.InvalidResponseError{Err: fmt.Errorf("Expected one choice, got %d", len(resp.Choices))}
+	}
 
-	oai.response = &openaiResp{
-		event:    *event,
-		metaData: md,
+	if refusal := resp.Choices[0].Message.Refusal; refusal != "" {
+		return llm.Metadata{}, &llm.InvalidResponseError{Raw: refusal, Err: errors.New("The model refused to answer")}
 	}
 
-	return md, nil
+	return llm.Parse(resp.Choices[0].Message.Content)
 }
@@ -0,0 +1,79 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer answers chat completions like an OpenAI compatible server and
+// records the requests.
+func fakeServer(t *testing.T, content string) (*httptest.Server, *[]map[string]any) {
+	var requests []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+
+		var request map[string]any
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":     "chatcmpl-1",
+			"object": "chat.completion",
+			"model":  request["model"],
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": content},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestExtract(t *testing.T) {
+	t.Run("extract with a local model", func(t *testing.T) {
+		server, requests := fakeServer(t, `{"artist": "Fury in the Slaughterhouse", "category": "concert"}`)
+		provider := New(Config{BaseUrl: server.URL + "/v1", Model: "llama3.2"})
+
+		metaData, err := provider.Extract(context.Background(), "Fury in the Slaughterhouse - Tour 2025")
+
+		assert.Nil(t, err)
+		assert.Equal(t, llm.Metadata{Artist: "Fury in the Slaughterhouse", Category: "concert"}, metaData)
+		assert.Len(t, *requests, 1)
+		assert.Equal(t, "llama3.2", (*requests)[0]["model"])
+
+		responseFormat := (*requests)[0]["response_format"].(map[string]any)
+		assert.Equal(t, "json_schema", responseFormat["type"])
+	})
+
+	t.Run("reject categories outside the schema", func(t *testing.T) {
+		server, _ := fakeServer(t, `{"artist": "artist-1", "category": "unkown"}`)
+		provider := New(Config{BaseUrl: server.URL + "/v1"})
+
+		_, err := provider.Extract(context.Background(), "artist-1")
+
+		var invalid *llm.InvalidResponseError
+		assert.ErrorAs(t, err, &invalid)
+		assert.Equal(t, `{"artist": "artist-1", "category": "unkown"}`, invalid.Raw)
+	})
+
+	t.Run("default model", func(t *testing.T) {
+		server, requests := fakeServer(t, `{"artist": "", "category": "party"}`)
+		provider := New(Config{BaseUrl: server.URL + "/v1"})
+
+		_, err := provider.Extract(context.Background(), "Ü30 Party")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "gpt-4o-mini", (*requests)[0]["model"])
+	})
+}
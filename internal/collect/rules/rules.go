@@ -0,0 +1,182 @@
+// Package rules fills the artist and the category of events from keyword and
+// pattern rules, without asking a language model.
+package rules
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// Text in parentheses is no part of the artist, e.g. "(ausverkauft)"
+var parentheses = regexp.MustCompile(`\s*\([^)]*\)`)
+
+type Rule struct {
+	Name     string   `yaml:"name"`
+	Keywords []string `yaml:"keywords"`
+	Pattern  string   `yaml:"pattern"`
+	Artist   string   `yaml:"artist"`
+	Category string   `yaml:"category"`
+
+	pattern *regexp.Regexp
+}
+
+// Load reads the rules from the file at path, or the embedded default rules
+// if path is empty.
+func Load(path string) (*Service, error) {
+	if path == "" {
+		return Parse(defaultRules)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return service, nil
+}
+
+func Parse(data []byte) (*Service, error) {
+	var file struct {
+		Rules []Rule `yaml:"rules"`
+	}
+
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Service{rules: file.Rules}, nil
+}
+
+func (r *Rule) compile() error {
+	if len(r.Keywords) == 0 && r.Pattern == "" {
+		return fmt.Errorf("Rule [%v] needs keywords or a pattern", r.Name)
+	}
+
+	if !lo.Contains(llm.CATEGORIES, r.Category) {
+		return fmt.Errorf("Rule [%v] has an unknown category [%v]", r.Name, r.Category)
+	}
+
+	if r.Pattern == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("Rule [%v] has an invalid pattern: %w", r.Name, err)
+	}
+
+	r.pattern = pattern
+
+	return nil
+}
+
+// match returns the metadata of the title, if the rule matches it.
+func (r *Rule) match(title string) (llm.Metadata, bool) {
+	if len(r.Keywords) > 0 && !lo.ContainsBy(r.Keywords, func(keyword string) bool {
+		return strings.Contains(strings.ToLower(title), strings.ToLower(keyword))
+	}) {
+		return llm.Metadata{}, false
+	}
+
+	metaData := llm.Metadata{Artist: r.Artist, Category: r.Category}
+
+	if r.pattern == nil {
+		return metaData, true
+	}
+
+	match := r.pattern.FindStringSubmatch(title)
+	if match == nil {
+		return llm.Metadata{}, false
+	}
+
+	if index := r.pattern.SubexpIndex("artist"); index >= 0 {
+		metaData.Artist = strings.TrimSpace(match[index])
+	}
+
+	return metaData, true
+}
+
+// Service implements collect.EventSyncCollector, it only knows the artist
+// and the category.
+type Service struct {
+	rules []Rule
+}
+
+// Match returns the metadata of the first rule that matches the title.
+func (s *Service) Match(title string) (llm.Metadata, bool) {
+	title = strings.TrimSpace(parentheses.ReplaceAllString(title, ""))
+
+	for i := range s.rules {
+		if metaData, ok := s.rules[i].match(title); ok {
+			return metaData, true
+		}
+	}
+
+	return llm.Metadata{}, false
+}
+
+// Knows reports if a rule matches the title of the event.
+func (s *Service) Knows(event *db.Event) bool {
+	_, ok := s.Match(event.Name)
+
+	return ok
+}
+
+func (s *Service) Init() error {
+	return nil
+}
+
+func (s *Service) SetArtist(event *db.Event) error {
+	if event.Artist.Valid {
+		return nil
+	}
+
+	if metaData, ok := s.Match(event.Name); ok && metaData.Artist != "" {
+		event.Artist = sql.NullString{String: metaData.Artist, Valid: true}
+	}
+
+	return nil
+}
+
+func (s *Service) SetCategory(event *db.Event) error {
+	if event.Category.Valid {
+		return nil
+	}
+
+	if metaData, ok := s.Match(event.Name); ok {
+		event.Category = sql.NullString{String: metaData.Category, Valid: true}
+	}
+
+	return nil
+}
+
+func (s *Service) SetArtistUrl(event *db.Event) error {
+	return nil
+}
+
+func (s *Service) SetArtistImgUrl(event *db.Event) error {
+	return nil
+}
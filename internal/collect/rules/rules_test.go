@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/collect/llm"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRules(t *testing.T) {
+	service, err := Load("")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		title    string
+		expected llm.Metadata
+		known    bool
+	}{
+		{"Sven Regener liest Wiener Straße", llm.Metadata{Artist: "Sven Regener", Category: "reading"}, true},
+		{"Poetry Slam im Zollhaus", llm.Metadata{Category: "reading"}, true},
+		{"Ü30 Party (ausverkauft)", llm.Metadata{Category: "party"}, true},
+		{"Bodo Wartke – Kabarett am Klavier", llm.Metadata{Artist: "Bodo Wartke", Category: "comedy"}, true},
+		{"Improtheater Bremen", llm.Metadata{Category: "theatre"}, true},
+		{"Kettcar - Gute Laune Ungerecht Verteilt Tour 2025 (Zusatzshow)", llm.Metadata{Artist: "Kettcar", Category: "concert"}, true},
+		{"Kettcar", llm.Metadata{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			metaData, ok := service.Match(test.title)
+
+			assert.Equal(t, test.known, ok)
+			assert.Equal(t, test.expected, metaData)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown category", "rules:\n  - name: jazz\n    keywords: [jazz]\n    category: jazz\n"},
+		{"invalid pattern", "rules:\n  - name: broken\n    pattern: '(?P<artist>'\n    category: concert\n"},
+		{"neither keywords nor pattern", "rules:\n  - name: empty\n    category: concert\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.data))
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestService(t *testing.T) {
+	service, err := Parse([]byte("rules:\n  - name: jazz\n    keywords: [jazz]\n    artist: Jazz Band\n    category: concert\n"))
+	assert.Nil(t, err)
+
+	t.Run("set artist and category", func(t *testing.T) {
+		event := db.Event{Name: "Jazz im Park"}
+
+		assert.Nil(t, service.SetArtist(&event))
+		assert.Nil(t, service.SetCategory(&event))
+
+		assert.True(t, service.Knows(&event))
+		assert.Equal(t, "Jazz Band", event.Artist.String)
+		assert.Equal(t, "concert", event.Category.String)
+	})
+
+	t.Run("leave unknown titles untouched", func(t *testing.T) {
+		event := db.Event{Name: "Rock im Park"}
+
+		assert.Nil(t, service.SetArtist(&event))
+		assert.Nil(t, service.SetCategory(&event))
+
+		assert.False(t, service.Knows(&event))
+		assert.False(t, event.Artist.Valid)
+		assert.False(t, event.Category.Valid)
+	})
+}
@@ -0,0 +1,71 @@
+package collect
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Source is a venue whose event listing can be crawled.
+type Source interface {
+	Name() string
+	Crawl() ([]Event, error)
+}
+
+var sources = map[string]Source{}
+
+func init() {
+	if err := registerEmbeddedDefinitions(); err != nil {
+		panic("Could not register embedded sources: " + err.Error())
+	}
+}
+
+// RegisterSource makes a source available under its name, replacing any
+// source that was registered with the same name before.
+func RegisterSource(source Source) {
+	sources[source.Name()] = source
+}
+
+func GetSource(name string) (Source, error) {
+	source, ok := sources[name]
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown source [%v]", name)
+	}
+
+	return source, nil
+}
+
+func SourceNames() []string {
+	names := make([]string, 0, len(sources))
+
+	for name := range sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// HtmlSource crawls a venue website as described by its definition.
+type HtmlSource struct {
+	Definition Definition
+}
+
+func (hs HtmlSource) Name() string {
+	return hs.Definition.Name
+}
+
+func (hs HtmlSource) Crawl() ([]Event, error) {
+	events, err := CrawlEvents(hs.Definition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		events[i].Source = hs.Name()
+	}
+
+	return events, nil
+}
@@ -0,0 +1,150 @@
+package collect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const listingPage = `<html><body>
+<div class="event">
+	<h3 class="title">Event 1</h3>
+	<span class="title">Sa., 02.11.2024</span>
+	<a href="%[1]v/event-1">more</a>
+</div>
+<div class="event">
+	<h3 class="title">Event 2</h3>
+	<span class="title">So., 03.11.2024</span>
+	<a href="/event-2">more</a>
+</div>
+</body></html>`
+
+const detailPage = `<html><body class="single">
+<img class="cover" src="/small.jpeg" data-lazy-src="/%[1]v.jpeg">
+<ul>
+	<li><i class="clock"></i><span>Einlass: 19.00 Uhr | Beginn: 20.00 Uhr</span></li>
+	<li><i class="pin"></i><span>Place of %[1]v</span></li>
+</ul>
+<a class="ticket">Tickets &amp; more</a>
+</body></html>`
+
+const testDefinition = `
+name: test
+url: %v
+selectors:
+  list: div.event
+  title: h3
+  link: a[href]
+  date:
+    selector: .title
+    pattern: '(?m)^(?:Sa|So)\., (?P<date>\d{2}\.\d{2}\.\d{4})$'
+    layout: 02.01.2006
+    default_time: "06:00"
+  detail: body.single
+  image:
+    selector: img.cover
+    attributes: [data-lazy-src, src]
+  place:
+    item: li
+    icon: i
+    icon_class: pin
+    text: span
+  status: a.ticket
+  start:
+    selector: li span
+    pattern: 'Beginn: (?P<time>\d{1,2}\.\d{2})'
+    layout: "15.04"
+  doors:
+    selector: li span
+    pattern: 'Einlass: (?P<time>\d{1,2}\.\d{2})'
+    layout: "15.04"
+`
+
+func TestCrawlEvents(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, listingPage, server.URL)
+		case "/event-1", "/event-2":
+			fmt.Fprintf(w, detailPage, r.URL.Path[1:])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	def, err := ParseDefinition([]byte(fmt.Sprintf(testDefinition, server.URL)))
+	assert.Nil(t, err)
+
+	events, err := HtmlSource{def}.Crawl()
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+
+	for i, event := range events {
+		slug := fmt.Sprintf("event-%v", i+1)
+
+		assert.Equal(t, fmt.Sprintf("Event %v", i+1), event.Name)
+		assert.Equal(t, server.URL+"/"+slug, event.Link)
+		assert.Equal(t, server.URL+"/"+slug+".jpeg", event.ArtistImgUrl)
+		assert.Equal(t, "Place of "+slug, event.Place)
+		assert.Equal(t, "Tickets & more", event.Status)
+		assert.Equal(t, "test", event.Source)
+		assert.Equal(t, time.Date(2024, 11, 2+i, 6, 0, 0, 0, time.Local), event.Date)
+		assert.Equal(t, time.Date(2024, 11, 2+i, 20, 0, 0, 0, time.Local), event.StartsAt)
+		assert.Equal(t, time.Date(2024, 11, 2+i, 19, 0, 0, 0, time.Local), event.DoorsAt)
+	}
+}
+
+func TestParseDefinition(t *testing.T) {
+	t.Run("embedded zollhaus definition is valid", func(t *testing.T) {
+		data, err := definitionFiles.ReadFile("sources/zollhaus.yaml")
+		assert.Nil(t, err)
+
+		def, err := ParseDefinition(data)
+		assert.Nil(t, err)
+		assert.Equal(t, "zollhaus", def.Name)
+	})
+
+	t.Run("missing selector", func(t *testing.T) {
+		_, err := ParseDefinition([]byte("name: test\nurl: http://localhost\n"))
+
+		assert.ErrorContains(t, err, "selectors.list")
+	})
+
+	t.Run("invalid date pattern", func(t *testing.T) {
+		definition := strings.Replace(fmt.Sprintf(testDefinition, "http://localhost"), "(?m)", "(?m", 1)
+
+		_, err := ParseDefinition([]byte(definition))
+
+		assert.ErrorContains(t, err, "invalid date pattern")
+	})
+
+	t.Run("time selector without pattern", func(t *testing.T) {
+		definition := strings.Replace(fmt.Sprintf(testDefinition, "http://localhost"), "pattern: 'Beginn", "unknown: 'Beginn", 1)
+
+		_, err := ParseDefinition([]byte(definition))
+
+		assert.ErrorContains(t, err, "selectors.start.pattern")
+	})
+}
+
+func TestGetSource(t *testing.T) {
+	t.Run("zollhaus is registered by default", func(t *testing.T) {
+		source, err := GetSource("zollhaus")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "zollhaus", source.Name())
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		_, err := GetSource("unknown")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal/db"
 
@@ -14,7 +15,12 @@ import (
 	"golang.org/x/oauth2/clientcredentials"
 )
 
-func New(id, secret string) *Service {
+// The artist found for a name is kept in the lookup cache for this long
+const CACHE_KIND = "spotify"
+const CACHE_TTL = 30 * 24 * time.Hour
+
+// New creates the service, cache may be nil to always search the artist.
+func New(id, secret string, cache db.LookupCacheRepository) *Service {
 	return &Service{
 		&clientcredentials.Config{
 			ClientID:     id,
@@ -22,6 +28,7 @@ func New(id, secret string) *Service {
 			TokenURL:     spotify.TokenURL,
 		},
 		nil,
+		cache,
 		nil,
 	}
 }
@@ -34,6 +41,7 @@ type spotifyResp struct {
 type Service struct {
 	auth     *clientcredentials.Config
 	client   *spotify.Client
+	cache    db.LookupCacheRepository
 	response *spotifyResp
 }
 
@@ -94,17 +102,39 @@ func (sp *Service) requestArtist(event *db.Event) (spotify.FullArtist, error) {
 		return sp.response.artist, nil
 	}
 
+	ctx := context.Background()
+
+	var artist spotify.FullArtist
+
+	if sp.cache != nil {
+		found, err := sp.cache.Get(ctx, CACHE_KIND, event.Artist.String, &artist)
+		if err != nil {
+			return spotify.FullArtist{}, err
+		}
+
+		if found {
+			sp.response = &spotifyResp{event: *event, artist: artist}
+
+			return artist, nil
+		}
+	}
+
 	result, err := sp.client.Search("artist:"+event.Artist.String, spotify.SearchTypeArtist)
 
 	if err != nil {
 		return spotify.FullArtist{}, err
 	}
 
-	if len(result.Artists.Artists) == 0 {
-		return spotify.FullArtist{}, nil
+	// Unknown artists are cached as well, to not search them on every run
+	if len(result.Artists.Artists) > 0 {
+		artist = filterArtist(event, result.Artists.Artists)
 	}
 
-	artist := filterArtist(event, result.Artists.Artists)
+	if sp.cache != nil {
+		if err := sp.cache.Put(ctx, CACHE_KIND, event.Artist.String, artist, CACHE_TTL); err != nil {
+			return spotify.FullArtist{}, err
+		}
+	}
 
 	sp.response = &spotifyResp{
 		event:  *event,
@@ -142,6 +142,29 @@ func TestCollectEventData(t *testing.T) {
 				ArtistImgUrl: sql.NullString{String: "collected.artist-img-url", Valid: true},
 			},
 		},
+		{
+			"test keep locked fields",
+			db.Event{
+				Artist:       sql.NullString{},
+				Category:     sql.NullString{String: "catergory", Valid: true},
+				ArtistUrl:    sql.NullString{},
+				ArtistImgUrl: sql.NullString{},
+				LockedFields: "artist,category,artist_url",
+			},
+			db.Event{
+				Artist:       sql.NullString{String: "collected.artist", Valid: true},
+				Category:     sql.NullString{String: "collected catergory", Valid: true},
+				ArtistUrl:    sql.NullString{String: "collected.artist.url", Valid: true},
+				ArtistImgUrl: sql.NullString{String: "collected.artist-img-url", Valid: true},
+			},
+			db.Event{
+				Artist:       sql.NullString{},
+				Category:     sql.NullString{String: "catergory", Valid: true},
+				ArtistUrl:    sql.NullString{},
+				ArtistImgUrl: sql.NullString{String: "collected.artist-img-url", Valid: true},
+				LockedFields: "artist,category,artist_url",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCacheRepo(t *testing.T) {
+	ctx := context.Background()
+
+	type entry struct {
+		Artist string
+	}
+
+	t.Run("prune expired entries", func(t *testing.T) {
+		cache := NewLookupCacheRepoFromConn(prepareConnection(t))
+
+		assert.Nil(t, cache.Put(ctx, "llm", "expired", entry{"artist-1"}, -time.Minute))
+		assert.Nil(t, cache.Put(ctx, "llm", "valid", entry{"artist-2"}, time.Hour))
+
+		assert.Nil(t, cache.Prune(ctx))
+
+		var count int
+		cache.conn.QueryRow("SELECT COUNT(*) FROM lookup_cache").Scan(&count)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("bypass entries on refresh", func(t *testing.T) {
+		cache := NewLookupCacheRepoFromConn(prepareConnection(t))
+		assert.Nil(t, cache.Put(ctx, "llm", "key", entry{"artist-1"}, time.Hour))
+
+		cache.Refresh = true
+
+		var cached entry
+		found, _ := cache.Get(ctx, "llm", "key", &cached)
+		assert.False(t, found)
+	})
+}
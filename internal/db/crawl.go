@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/status"
+)
+
+const REMOVED_STATUS = "Removed from the listing"
+
+// CrawlRunRepository records which events a crawl run saw, to find the events
+// that disappeared from the listing of their source.
+type CrawlRunRepository interface {
+	Record(ctx context.Context, run CrawlRunResult) ([]Event, error)
+}
+
+type CrawlRunResult struct {
+	Source string
+	Links  []string
+	// Upcoming events missing in this many consecutive runs are flagged as removed
+	RemoveAfter int
+	// Record a cancellation for removed events, so they are announced
+	AnnounceRemoved bool
+	Now             time.Time
+}
+
+func NewCrawlRunRepoFromConn(conn *sql.DB) *CrawlRunRepo {
+	return &CrawlRunRepo{Queries: New(conn), conn: conn}
+}
+
+type CrawlRunRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+}
+
+func (cr *CrawlRunRepo) Record(ctx context.Context, run CrawlRunResult) ([]Event, error) {
+	return RecordCrawlRun(ctx, cr.conn, cr.withTx, run)
+}
+
+func (cr *CrawlRunRepo) withTx(tx *sql.Tx) CrawlRunStore {
+	return cr.Queries.WithTx(tx)
+}
+
+// CrawlRunStore holds the queries RecordCrawlRun runs. Queries implements
+// it, the other database backends adapt their queries.
+type CrawlRunStore interface {
+	AddCrawlRunLink(ctx context.Context, arg AddCrawlRunLinkParams) error
+	CreateCrawlRun(ctx context.Context, arg CreateCrawlRunParams) (int64, error)
+	CreateEventRevision(ctx context.Context, arg CreateEventRevisionParams) error
+	CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error
+	GetEvent(ctx context.Context, id int64) (Event, error)
+	GetMissingEvents(ctx context.Context, arg GetMissingEventsParams) ([]Event, error)
+	GetReappearedEvents(ctx context.Context, arg GetReappearedEventsParams) ([]Event, error)
+	GetUnseenEvents(ctx context.Context, arg GetUnseenEventsParams) ([]Event, error)
+	IncrementMissingRuns(ctx context.Context, id int64) error
+	MarkEventRemoved(ctx context.Context, arg MarkEventRemovedParams) error
+	MarkEventSeen(ctx context.Context, id int64) error
+}
+
+// RecordCrawlRun stores the run and returns the events that were flagged as
+// removed by it. Events that show up again lose the flag.
+func RecordCrawlRun(ctx context.Context, conn *sql.DB, withTx func(tx *sql.Tx) CrawlRunStore, run CrawlRunResult) ([]Event, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	queries := withTx(tx)
+
+	runId, err := queries.CreateCrawlRun(ctx, CreateCrawlRunParams{Source: run.Source, EventCount: int64(len(run.Links))})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range run.Links {
+		if err := queries.AddCrawlRunLink(ctx, AddCrawlRunLinkParams{CrawlRunID: runId, Link: link}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Every write goes through trackRevisions, so the history shows when an
+	// event went missing and when it showed up again
+	reappeared, err := queries.GetReappearedEvents(ctx, GetReappearedEventsParams{Source: run.Source, CrawlRunID: runId})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range reappeared {
+		err := trackRevisions(ctx, queries, event.ID, func() error {
+			return queries.MarkEventSeen(ctx, event.ID)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unseen, err := queries.GetUnseenEvents(ctx, GetUnseenEventsParams{Source: run.Source, Date: run.Now, CrawlRunID: runId})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range unseen {
+		err := trackRevisions(ctx, queries, event.ID, func() error {
+			return queries.IncrementMissingRuns(ctx, event.ID)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	removed, err := queries.GetMissingEvents(ctx, GetMissingEventsParams{Source: run.Source, MissingRuns: int64(run.RemoveAfter)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range removed {
+		err := trackRevisions(ctx, queries, event.ID, func() error {
+			return queries.MarkEventRemoved(ctx, MarkEventRemovedParams{
+				RemovedAt: sql.NullTime{Time: run.Now, Valid: true},
+				ID:        event.ID,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !run.AnnounceRemoved {
+			continue
+		}
+
+		err = queries.CreateStatusChange(ctx, CreateStatusChangeParams{
+			EventID:   event.ID,
+			OldStatus: event.Status,
+			NewStatus: REMOVED_STATUS,
+			Kind:      status.CANCELLED,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,68 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type LookupCache interface {
+	db.LookupCacheRepository
+	Prune(ctx context.Context) error
+}
+
+// TestLookupCacheRepository runs the suite of the lookup cache, newCache has
+// to return an empty cache on every call.
+func TestLookupCacheRepository(t *testing.T, newCache func(t *testing.T) LookupCache) {
+	ctx := context.Background()
+
+	type entry struct {
+		Artist string
+	}
+
+	t.Run("read entries by the normalised key", func(t *testing.T) {
+		cache := newCache(t)
+
+		assert.Nil(t, cache.Put(ctx, "llm", "Artist-1  Tour", entry{"artist-1"}, time.Hour))
+
+		var cached entry
+		found, err := cache.Get(ctx, "llm", " artist-1 tour", &cached)
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "artist-1", cached.Artist)
+
+		found, _ = cache.Get(ctx, "spotify", "artist-1 tour", &cached)
+		assert.False(t, found)
+	})
+
+	t.Run("replace existing entries", func(t *testing.T) {
+		cache := newCache(t)
+
+		assert.Nil(t, cache.Put(ctx, "llm", "key", entry{"artist-1"}, time.Hour))
+		assert.Nil(t, cache.Put(ctx, "llm", "key", entry{"artist-2"}, time.Hour))
+
+		var cached entry
+		cache.Get(ctx, "llm", "key", &cached)
+		assert.Equal(t, "artist-2", cached.Artist)
+	})
+
+	t.Run("ignore and prune expired entries", func(t *testing.T) {
+		cache := newCache(t)
+
+		assert.Nil(t, cache.Put(ctx, "llm", "expired", entry{"artist-1"}, -time.Minute))
+		assert.Nil(t, cache.Put(ctx, "llm", "valid", entry{"artist-2"}, time.Hour))
+
+		var cached entry
+		found, err := cache.Get(ctx, "llm", "expired", &cached)
+		assert.Nil(t, err)
+		assert.False(t, found)
+
+		assert.Nil(t, cache.Prune(ctx))
+
+		found, _ = cache.Get(ctx, "llm", "valid", &cached)
+		assert.True(t, found)
+	})
+}
@@ -0,0 +1,117 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/status"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCrawlRunRepository runs the suite of the crawl runs, newRepos has to
+// return empty repositories sharing one database on every call.
+func TestCrawlRunRepository(t *testing.T, newRepos func(t *testing.T) (Repository, db.CrawlRunRepository)) {
+	ctx := context.Background()
+
+	prepare := func(t *testing.T) (Repository, db.CrawlRunRepository, map[string]int64) {
+		eventRepo, crawlRunRepo := newRepos(t)
+		ids := map[string]int64{}
+
+		for _, event := range []db.Event{
+			{Name: "event-1", Link: "link-1", Date: time.Now().AddDate(0, 0, 1), Source: "zollhaus"},
+			{Name: "event-2", Link: "link-2", Date: time.Now().AddDate(0, 0, 2), Source: "zollhaus"},
+			{Name: "event-3", Link: "link-3", Date: time.Now().AddDate(0, 0, -2), Source: "zollhaus"},
+			{Name: "event-4", Link: "link-4", Date: time.Now().AddDate(0, 0, 2), Source: "other"},
+		} {
+			assert.Nil(t, eventRepo.Save(ctx, event))
+
+			stored, err := eventRepo.GetByLink(ctx, event.Link)
+			assert.Nil(t, err)
+			ids[event.Link] = stored.ID
+		}
+
+		return eventRepo, crawlRunRepo, ids
+	}
+
+	changesOf := func(t *testing.T, eventRepo Repository, id int64) []db.StatusChange {
+		changes, err := eventRepo.GetUnannouncedStatusChanges(ctx, time.Now().AddDate(0, 0, -7))
+		assert.Nil(t, err)
+
+		return lo.Filter(changes, func(change db.StatusChange, _ int) bool {
+			return change.EventID == id
+		})
+	}
+
+	run := db.CrawlRunResult{Source: "zollhaus", Links: []string{"link-1"}, RemoveAfter: 2, Now: time.Now()}
+
+	t.Run("flag upcoming events missing in consecutive runs", func(t *testing.T) {
+		eventRepo, crawlRunRepo, ids := prepare(t)
+
+		removed, err := crawlRunRepo.Record(ctx, run)
+		assert.Nil(t, err)
+		assert.Len(t, removed, 0)
+
+		removed, err = crawlRunRepo.Record(ctx, run)
+		assert.Nil(t, err)
+		assert.Len(t, removed, 1)
+		assert.Equal(t, "link-2", removed[0].Link)
+
+		// Past events and other sources are left alone
+		for link, removedAt := range map[string]bool{"link-1": false, "link-2": true, "link-3": false, "link-4": false} {
+			event, _ := eventRepo.GetById(ctx, ids[link])
+			assert.Equal(t, removedAt, event.RemovedAt.Valid, link)
+		}
+
+		fresh, _ := eventRepo.GetFreshEvents(ctx)
+		assert.Len(t, fresh, 3)
+		for _, event := range fresh {
+			assert.NotEqual(t, "link-2", event.Link)
+		}
+
+		assert.Len(t, changesOf(t, eventRepo, ids["link-2"]), 0)
+	})
+
+	t.Run("reset events that show up again", func(t *testing.T) {
+		eventRepo, crawlRunRepo, ids := prepare(t)
+
+		crawlRunRepo.Record(ctx, run)
+		crawlRunRepo.Record(ctx, run)
+		_, err := crawlRunRepo.Record(ctx, db.CrawlRunResult{Source: "zollhaus", Links: []string{"link-1", "link-2", "link-2"}, RemoveAfter: 2, Now: time.Now()})
+		assert.Nil(t, err)
+
+		event, _ := eventRepo.GetById(ctx, ids["link-2"])
+		assert.False(t, event.RemovedAt.Valid)
+		assert.Equal(t, int64(0), event.MissingRuns)
+
+		revisions, _ := eventRepo.GetRevisions(ctx, ids["link-2"])
+		fields := []string{}
+		for _, revision := range revisions {
+			fields = append(fields, revision.Field+" "+revision.OldValue+" -> "+revision.NewValue)
+		}
+		assert.Equal(t, "missing_runs 0 -> 1", fields[0])
+		assert.Equal(t, "missing_runs 1 -> 2", fields[1])
+		assert.Equal(t, "removed_at", revisions[2].Field)
+		assert.Equal(t, "missing_runs 2 -> 0", fields[3])
+		assert.Equal(t, "removed_at", revisions[4].Field)
+		assert.Equal(t, "", revisions[4].NewValue)
+		assert.Len(t, revisions, 5)
+	})
+
+	t.Run("announce removed events as cancelled", func(t *testing.T) {
+		eventRepo, crawlRunRepo, ids := prepare(t)
+		run := run
+		run.RemoveAfter = 1
+		run.AnnounceRemoved = true
+
+		crawlRunRepo.Record(ctx, run)
+		crawlRunRepo.Record(ctx, run)
+
+		changes := changesOf(t, eventRepo, ids["link-2"])
+		assert.Len(t, changes, 1)
+		assert.Equal(t, status.CANCELLED, changes[0].Kind)
+		assert.Equal(t, db.REMOVED_STATUS, changes[0].NewStatus)
+	})
+}
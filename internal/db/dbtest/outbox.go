@@ -0,0 +1,143 @@
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOutboxRepository runs the suite of the outbox, newRepos has to return
+// empty repositories sharing one database on every call.
+func TestOutboxRepository(t *testing.T, newRepos func(t *testing.T) (Repository, db.OutboxRepository)) {
+	ctx := context.Background()
+
+	prepare := func(t *testing.T) (Repository, db.OutboxRepository, db.Outbox) {
+		eventRepo, outboxRepo := newRepos(t)
+
+		assert.Nil(t, eventRepo.Save(ctx, db.Event{Name: "event-1", Link: "link-1", Date: time.Now()}))
+		event, err := eventRepo.GetByLink(ctx, "link-1")
+		assert.Nil(t, err)
+
+		message := db.Outbox{EventID: event.ID, Kind: db.OUTBOX_KIND_FRESH, Receiver: "receiver", Revision: "rev-1", NextRetryAt: time.Now()}
+
+		return eventRepo, outboxRepo, message
+	}
+
+	t.Run("enqueue a message once", func(t *testing.T) {
+		_, outboxRepo, message := prepare(t)
+
+		assert.Nil(t, outboxRepo.Enqueue(ctx, message))
+		assert.Nil(t, outboxRepo.Enqueue(ctx, message))
+
+		due, err := outboxRepo.GetDue(ctx, time.Now(), 3)
+		assert.Nil(t, err)
+		assert.Len(t, due, 1)
+	})
+
+	t.Run("failed messages are due after the retry time", func(t *testing.T) {
+		_, outboxRepo, message := prepare(t)
+		outboxRepo.Enqueue(ctx, message)
+		due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+
+		assert.Nil(t, outboxRepo.MarkFailed(ctx, due[0], errors.New("offline"), time.Now().Add(time.Hour)))
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now(), 3)
+		assert.Len(t, due, 0)
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now().Add(2*time.Hour), 3)
+		assert.Len(t, due, 1)
+		assert.Equal(t, int64(1), due[0].Attempts)
+		assert.Equal(t, "offline", due[0].LastError.String)
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now().Add(2*time.Hour), 1)
+		assert.Len(t, due, 0)
+	})
+
+	t.Run("discard a message", func(t *testing.T) {
+		_, outboxRepo, message := prepare(t)
+		outboxRepo.Enqueue(ctx, message)
+		due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+
+		assert.Nil(t, outboxRepo.Discard(ctx, due[0]))
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now(), 3)
+		assert.Len(t, due, 0)
+	})
+
+	t.Run("mark the event as reported after the last delivery", func(t *testing.T) {
+		eventRepo, outboxRepo, message := prepare(t)
+		outboxRepo.Enqueue(ctx, message)
+		other := message
+		other.Receiver = "other-receiver"
+		outboxRepo.Enqueue(ctx, other)
+		due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+
+		assert.Nil(t, outboxRepo.MarkDelivered(ctx, due[0], time.Now()))
+		event, _ := eventRepo.GetById(ctx, message.EventID)
+		assert.False(t, event.ReportedAtNew.Valid)
+
+		assert.Nil(t, outboxRepo.MarkDelivered(ctx, due[1], time.Now()))
+		event, _ = eventRepo.GetById(ctx, message.EventID)
+		assert.True(t, event.ReportedAtNew.Valid)
+		assert.False(t, event.ReportedAtUpcoming.Valid)
+
+		revisions, _ := eventRepo.GetRevisions(ctx, message.EventID)
+		assert.Len(t, revisions, 1)
+		assert.Equal(t, "reported_at_new", revisions[0].Field)
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now(), 3)
+		assert.Len(t, due, 0)
+	})
+
+	t.Run("mark digests as upcoming reported", func(t *testing.T) {
+		eventRepo, outboxRepo, message := prepare(t)
+		assert.Nil(t, eventRepo.Save(ctx, db.Event{Name: "event-2", Link: "link-2", Date: time.Now()}))
+		second, _ := eventRepo.GetByLink(ctx, "link-2")
+
+		from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+		digest := message
+		digest.Kind = db.OUTBOX_KIND_DIGEST
+		digest.Revision = db.DigestRevision(from, from.AddDate(0, 0, 14))
+		outboxRepo.Enqueue(ctx, digest)
+		digest.EventID = second.ID
+		outboxRepo.Enqueue(ctx, digest)
+		due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+
+		assert.Nil(t, outboxRepo.MarkAllDelivered(ctx, due, time.Now()))
+
+		for _, id := range []int64{message.EventID, second.ID} {
+			event, _ := eventRepo.GetById(ctx, id)
+			assert.True(t, event.ReportedAtUpcoming.Valid)
+
+			revisions, _ := eventRepo.GetRevisions(ctx, id)
+			assert.Len(t, revisions, 1)
+			assert.Equal(t, "reported_at_upcoming", revisions[0].Field)
+		}
+
+		due, _ = outboxRepo.GetDue(ctx, time.Now(), 3)
+		assert.Len(t, due, 0)
+	})
+
+	t.Run("mark status changes as announced", func(t *testing.T) {
+		eventRepo, outboxRepo, _ := prepare(t)
+		event, _ := eventRepo.GetByLink(ctx, "link-1")
+		event.Status = "Abgesagt"
+		assert.Nil(t, eventRepo.Save(ctx, event))
+
+		changes, _ := eventRepo.GetUnannouncedStatusChanges(ctx, time.Now().AddDate(0, 0, -1))
+		assert.Len(t, changes, 1)
+
+		message := db.Outbox{EventID: event.ID, Kind: db.OUTBOX_KIND_STATUS, Receiver: "receiver", Revision: db.StatusRevision(changes[0]), NextRetryAt: time.Now()}
+		outboxRepo.Enqueue(ctx, message)
+		due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+
+		assert.Nil(t, outboxRepo.MarkDelivered(ctx, due[0], time.Now()))
+
+		changes, _ = eventRepo.GetUnannouncedStatusChanges(ctx, time.Now().AddDate(0, 0, -1))
+		assert.Len(t, changes, 0)
+	})
+}
@@ -0,0 +1,212 @@
+// Package dbtest holds the test suite every event repository has to pass.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/status"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+type Repository interface {
+	db.EventRepository
+	GetMetadataFailures(ctx context.Context, eventId int64) ([]db.MetadataFailure, error)
+	RecordMetadataFailure(ctx context.Context, eventId int64, rawResponse string, reason string) error
+}
+
+// TestEventRepository runs the suite, newRepo has to return an empty
+// repository on every call.
+func TestEventRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	today := time.Now().Truncate(time.Second)
+
+	save := func(t *testing.T, repo Repository, event db.Event) db.Event {
+		assert.Nil(t, repo.Save(ctx, event))
+
+		stored, err := repo.GetByLink(ctx, event.Link)
+		assert.Nil(t, err)
+
+		return stored
+	}
+
+	links := func(events []db.Event) []string {
+		return lo.Map(events, func(event db.Event, _ int) string {
+			return event.Link
+		})
+	}
+
+	t.Run("create and read an event", func(t *testing.T) {
+		repo := newRepo(t)
+		startsAt := sql.NullTime{Time: today.Add(time.Hour), Valid: true}
+
+		event := save(t, repo, db.Event{Name: "event-1", Place: "place-1", Status: "Tickets", Link: "link-1", Date: today, Source: "source-1", StartsAt: startsAt})
+
+		assert.NotZero(t, event.ID)
+		assert.Equal(t, "event-1", event.Name)
+		assert.Equal(t, "place-1", event.Place)
+		assert.Equal(t, "source-1", event.Source)
+		assert.True(t, today.Equal(event.Date))
+		assert.True(t, startsAt.Time.Equal(event.StartsAt.Time))
+		assert.False(t, event.DoorsAt.Valid)
+
+		byId, err := repo.GetById(ctx, event.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, event.Link, byId.Link)
+
+		_, err = repo.GetByLink(ctx, "unknown")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("create an existing link updates the event", func(t *testing.T) {
+		repo := newRepo(t)
+
+		save(t, repo, db.Event{Name: "event-1", Status: "Tickets", Link: "link-1", Date: today})
+		event := save(t, repo, db.Event{Name: "event-2", Status: "Ausverkauft", Link: "link-1", Date: today})
+
+		assert.Equal(t, "event-2", event.Name)
+
+		revisions, _ := repo.GetRevisions(ctx, event.ID)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, []string{"name", "status"}, []string{revisions[0].Field, revisions[1].Field})
+
+		changes, _ := repo.GetUnannouncedStatusChanges(ctx, today)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("update records revisions and status changes", func(t *testing.T) {
+		repo := newRepo(t)
+		event := save(t, repo, db.Event{Name: "event-1", Status: "Tickets", Link: "link-1", Date: today.AddDate(0, 0, 1)})
+
+		assert.Nil(t, repo.Save(ctx, event))
+		revisions, err := repo.GetRevisions(ctx, event.ID)
+		assert.Nil(t, err)
+		assert.Len(t, revisions, 0)
+
+		event.Name = "event-2"
+		event.Status = "Ausverkauft"
+		assert.Nil(t, repo.Save(ctx, event))
+
+		revisions, _ = repo.GetRevisions(ctx, event.ID)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, []string{"name", "event-1", "event-2"}, []string{revisions[0].Field, revisions[0].OldValue, revisions[0].NewValue})
+		assert.Equal(t, []string{"status", "Tickets", "Ausverkauft"}, []string{revisions[1].Field, revisions[1].OldValue, revisions[1].NewValue})
+
+		changes, err := repo.GetUnannouncedStatusChanges(ctx, today)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, status.SOLD_OUT, changes[0].Kind)
+		assert.Equal(t, event.ID, changes[0].EventID)
+
+		change, err := repo.GetStatusChange(ctx, changes[0].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, changes[0], change)
+
+		changes, _ = repo.GetUnannouncedStatusChanges(ctx, today.AddDate(0, 0, 2))
+		assert.Len(t, changes, 0)
+	})
+
+	t.Run("update records postponements", func(t *testing.T) {
+		repo := newRepo(t)
+		dates := []time.Time{today.AddDate(0, 0, 5), today.AddDate(0, 0, 10), today.AddDate(0, 0, 20)}
+		event := save(t, repo, db.Event{Name: "event-1", Link: "link-1", Date: dates[0]})
+
+		for i := 1; i < len(dates); i++ {
+			event.PostponedDate = sql.NullTime{Time: event.Date, Valid: true}
+			event.Date = dates[i]
+			assert.Nil(t, repo.Save(ctx, event))
+		}
+
+		assert.Nil(t, repo.Save(ctx, event))
+
+		postponements, err := repo.GetPostponements(ctx, event.ID)
+		assert.Nil(t, err)
+		assert.Len(t, postponements, 2)
+		assert.True(t, dates[0].Equal(postponements[0].PreviousDate))
+		assert.True(t, dates[2].Equal(postponements[1].NewDate))
+	})
+
+	t.Run("read events by period", func(t *testing.T) {
+		repo := newRepo(t)
+		from := time.Date(today.Year(), today.Month(), 1, 20, 0, 0, 0, time.Local).AddDate(0, 1, 0)
+
+		save(t, repo, db.Event{Name: "event-1", Link: "link-1", Date: from.AddDate(0, 0, -1)})
+		save(t, repo, db.Event{Name: "event-2", Link: "link-2", Date: from.AddDate(0, 0, 1)})
+		save(t, repo, db.Event{Name: "event-3", Link: "link-3", Date: from})
+		reported := save(t, repo, db.Event{Name: "event-4", Link: "link-4", Date: from.AddDate(0, 0, 2)})
+		reported.ReportedAtUpcoming = sql.NullTime{Time: today, Valid: true}
+		assert.Nil(t, repo.Save(ctx, reported))
+		save(t, repo, db.Event{Name: "event-5", Link: "link-5", Date: from.AddDate(0, 2, 0)})
+
+		between, err := repo.GetEventsBetween(ctx, from, from.AddDate(0, 0, 2))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"link-3", "link-2", "link-4"}, links(between))
+
+		upcoming, err := repo.GetUpcomingEvents(ctx, from, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"link-3", "link-2"}, links(upcoming))
+	})
+
+	t.Run("read fresh and naked events", func(t *testing.T) {
+		repo := newRepo(t)
+
+		save(t, repo, db.Event{Name: "event-1", Link: "link-1", Date: today.AddDate(0, 0, 2)})
+		reported := save(t, repo, db.Event{Name: "event-2", Link: "link-2", Date: today.AddDate(0, 0, 1)})
+		reported.ReportedAtNew = sql.NullTime{Time: today, Valid: true}
+		reported.Artist = sql.NullString{String: "artist", Valid: true}
+		reported.Category = sql.NullString{String: "concert", Valid: true}
+		reported.ArtistUrl = sql.NullString{String: "artist-url", Valid: true}
+		reported.ArtistImgUrl = sql.NullString{String: "artist-img-url", Valid: true}
+		assert.Nil(t, repo.Save(ctx, reported))
+
+		fresh, err := repo.GetFreshEvents(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"link-1"}, links(fresh))
+
+		naked, err := repo.GetNakedEvents(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"link-1"}, links(naked))
+	})
+	t.Run("skip locked fields for naked events", func(t *testing.T) {
+		repo := newRepo(t)
+
+		event := save(t, repo, db.Event{Name: "event-1", Link: "link-1", Date: today.AddDate(0, 0, 1)})
+		assert.Nil(t, event.Override(db.FIELD_ARTIST, "artist"))
+		assert.Nil(t, event.Override(db.FIELD_CATEGORY, "concert"))
+		assert.Nil(t, event.Override(db.FIELD_ARTIST_URL, ""))
+		event.ArtistImgUrl = sql.NullString{String: "artist-img-url", Valid: true}
+		assert.Nil(t, repo.Save(ctx, event))
+
+		stored, err := repo.GetById(ctx, event.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{db.FIELD_ARTIST, db.FIELD_CATEGORY, db.FIELD_ARTIST_URL}, stored.Locks())
+
+		naked, err := repo.GetNakedEvents(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, naked, 0)
+
+		assert.Nil(t, stored.Unlock(db.FIELD_ARTIST))
+		assert.Nil(t, repo.Save(ctx, stored))
+
+		naked, err = repo.GetNakedEvents(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"link-1"}, links(naked))
+	})
+
+	t.Run("record metadata failures", func(t *testing.T) {
+		repo := newRepo(t)
+		event := save(t, repo, db.Event{Name: "event-1", Link: "link-1", Date: today})
+
+		assert.Nil(t, repo.RecordMetadataFailure(ctx, event.ID, `{"category": "unkown"}`, "Unknown category [unkown]"))
+
+		failures, err := repo.GetMetadataFailures(ctx, event.ID)
+		assert.Nil(t, err)
+		assert.Len(t, failures, 1)
+		assert.Equal(t, `{"category": "unkown"}`, failures[0].RawResponse)
+		assert.Equal(t, "Unknown category [unkown]", failures[0].Error)
+	})
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// The metadata fields that can be set by hand. A locked field is left alone
+// by the metadata enrichment and the crawler.
+const FIELD_ARTIST = "artist"
+const FIELD_CATEGORY = "category"
+const FIELD_ARTIST_URL = "artist_url"
+const FIELD_ARTIST_IMG_URL = "artist_img_url"
+
+var LOCKABLE_FIELDS = []string{FIELD_ARTIST, FIELD_CATEGORY, FIELD_ARTIST_URL, FIELD_ARTIST_IMG_URL}
+
+// Locks returns the locked fields in the order of LOCKABLE_FIELDS.
+func (e Event) Locks() []string {
+	return sortLocks(strings.Split(e.LockedFields, ","))
+}
+
+func (e Event) Locked(field string) bool {
+	return lo.Contains(e.Locks(), field)
+}
+
+// Override sets the field by hand and locks it. An empty value clears the
+// field, it stays empty until it is unlocked.
+func (e *Event) Override(field string, value string) error {
+	target, err := e.metadataField(field)
+	if err != nil {
+		return err
+	}
+
+	*target = sql.NullString{String: value, Valid: value != ""}
+	e.LockedFields = strings.Join(sortLocks(append(e.Locks(), field)), ",")
+
+	return nil
+}
+
+// Unlock hands the field back to the enrichment. The value is cleared, so
+// the field is filled again by the next metadata run.
+func (e *Event) Unlock(field string) error {
+	target, err := e.metadataField(field)
+	if err != nil {
+		return err
+	}
+
+	*target = sql.NullString{}
+	e.LockedFields = strings.Join(lo.Without(e.Locks(), field), ",")
+
+	return nil
+}
+
+func (e *Event) metadataField(field string) (*sql.NullString, error) {
+	switch field {
+	case FIELD_ARTIST:
+		return &e.Artist, nil
+	case FIELD_CATEGORY:
+		return &e.Category, nil
+	case FIELD_ARTIST_URL:
+		return &e.ArtistUrl, nil
+	case FIELD_ARTIST_IMG_URL:
+		return &e.ArtistImgUrl, nil
+	}
+
+	return nil, fmt.Errorf("Unknown field [%v]", field)
+}
+
+func sortLocks(locks []string) []string {
+	return lo.Filter(LOCKABLE_FIELDS, func(field string, _ int) bool {
+		return lo.Contains(locks, field)
+	})
+}
@@ -0,0 +1,277 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DIALECT_SQLITE = "sqlite3"
+const DIALECT_POSTGRES = "postgres"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change, the files in migrations are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt sql.NullTime
+}
+
+// Migrations returns the embedded migrations, ordered by version.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadMigrations(files)
+}
+
+// ReadMigrations reads the migrations from the root of files, ordered by version.
+func ReadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("Invalid migration file name [%v]", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("Migration [%v] has two names, [%v] and [%v]", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("Migration [%v_%v] has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func NewMigrator(conn *sql.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMigratorWithMigrations(conn, DIALECT_SQLITE, migrations), nil
+}
+
+// NewMigratorWithMigrations runs the given migrations, e.g. the ones of
+// another database dialect.
+func NewMigratorWithMigrations(conn *sql.DB, dialect string, migrations []Migration) *Migrator {
+	return &Migrator{conn: conn, dialect: dialect, migrations: migrations}
+}
+
+// Migrator applies the migrations and records them in schema_migrations. The
+// whatsmeow store keeps its own version table in the same database.
+type Migrator struct {
+	conn       *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// Migrate applies every pending migration.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	migrator, err := NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(ctx)
+
+	return err
+}
+
+// Up applies the pending migrations in order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, status := range statuses {
+		if status.AppliedAt.Valid {
+			continue
+		}
+
+		err := m.apply(ctx, status.Migration, status.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				m.bind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+				status.Version,
+				status.Name,
+				time.Now(),
+			)
+			return err
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+// Down reverts the last applied migration. It returns false, if there was
+// nothing to revert.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		migration := statuses[i].Migration
+
+		if !statuses[i].AppliedAt.Valid {
+			continue
+		}
+
+		if migration.Down == "" {
+			return migration, false, fmt.Errorf("Migration [%v_%v] can't be reverted", migration.Version, migration.Name)
+		}
+
+		err := m.apply(ctx, migration, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.bind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+			return err
+		})
+
+		return migration, err == nil, err
+	}
+
+	return Migration{}, false, nil
+}
+
+// Status lists every migration along with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	_, err := m.conn.ExecContext(ctx, `create table if not exists schema_migrations
+(
+    version INTEGER not null constraint schema_migrations_pk primary key,
+    name TEXT not null,
+    applied_at TIMESTAMP not null
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int64]time.Time{}
+
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			statuses[i].AppliedAt = sql.NullTime{Time: at, Valid: true}
+		}
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration, statements string, record func(tx *sql.Tx) error) error {
+	tx, err := m.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return fmt.Errorf("Migration [%v_%v] failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// bind rewrites the ? placeholders of the query for the dialect.
+func (m *Migrator) bind(query string) string {
+	if m.dialect != DIALECT_POSTGRES {
+		return query
+	}
+
+	parts := strings.Split(query, "?")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = "$" + strconv.Itoa(i) + parts[i]
+	}
+
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	prepare := func(t *testing.T) (*sql.DB, *Migrator) {
+		conn, err := sql.Open("sqlite3", ":memory:")
+		assert.Nil(t, err)
+		conn.SetMaxOpenConns(1)
+
+		migrator, err := NewMigrator(conn)
+		assert.Nil(t, err)
+
+		return conn, migrator
+	}
+
+	tableExists := func(t *testing.T, conn *sql.DB, table string) bool {
+		var count int
+		err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+		assert.Nil(t, err)
+		return count == 1
+	}
+
+	t.Run("migrations are ordered and complete", func(t *testing.T) {
+		migrations, err := Migrations()
+		assert.Nil(t, err)
+		assert.NotEmpty(t, migrations)
+
+		for i, migration := range migrations {
+			assert.Equal(t, int64(i+1), migration.Version)
+			assert.NotEmpty(t, migration.Up)
+			assert.NotEmpty(t, migration.Down)
+		}
+	})
+
+	t.Run("up applies every migration once", func(t *testing.T) {
+		conn, migrator := prepare(t)
+		migrations, _ := Migrations()
+
+		applied, err := migrator.Up(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, applied, len(migrations))
+		assert.True(t, tableExists(t, conn, "events"))
+		assert.True(t, tableExists(t, conn, "event_postponements"))
+
+		applied, err = migrator.Up(ctx)
+		assert.Nil(t, err)
+		assert.Empty(t, applied)
+
+		statuses, err := migrator.Status(ctx)
+		assert.Nil(t, err)
+		for _, status := range statuses {
+			assert.True(t, status.AppliedAt.Valid)
+		}
+	})
+
+	t.Run("down reverts the last migration", func(t *testing.T) {
+		_, migrator := prepare(t)
+		migrations, _ := Migrations()
+		last := migrations[len(migrations)-1]
+
+		_, err := migrator.Up(ctx)
+		assert.Nil(t, err)
+
+		migration, reverted, err := migrator.Down(ctx)
+		assert.Nil(t, err)
+		assert.True(t, reverted)
+		assert.Equal(t, last.Version, migration.Version)
+
+		statuses, err := migrator.Status(ctx)
+		assert.Nil(t, err)
+		assert.False(t, statuses[len(statuses)-1].AppliedAt.Valid)
+		assert.True(t, statuses[len(statuses)-2].AppliedAt.Valid)
+
+		// Applying it again only works if down reverted the schema change
+		applied, err := migrator.Up(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, applied, 1)
+	})
+
+	t.Run("down reverts everything", func(t *testing.T) {
+		conn, migrator := prepare(t)
+		migrations, _ := Migrations()
+
+		_, err := migrator.Up(ctx)
+		assert.Nil(t, err)
+
+		for range migrations {
+			_, reverted, err := migrator.Down(ctx)
+			assert.Nil(t, err)
+			assert.True(t, reverted)
+		}
+
+		_, reverted, err := migrator.Down(ctx)
+		assert.Nil(t, err)
+		assert.False(t, reverted)
+		assert.False(t, tableExists(t, conn, "events"))
+	})
+
+	t.Run("baseline keeps an existing events table", func(t *testing.T) {
+		conn, migrator := prepare(t)
+
+		_, err := conn.Exec(migrator.migrations[0].Up)
+		assert.Nil(t, err)
+		_, err = conn.Exec("INSERT INTO events (name, place, status, link, date) VALUES ('event-1', 'place-1', 'status-1', 'link-1', '2024-01-01')")
+		assert.Nil(t, err)
+
+		_, err = migrator.Up(ctx)
+		assert.Nil(t, err)
+
+		event, err := New(conn).GetEventByLink(ctx, "link-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "event-1", event.Name)
+	})
+}
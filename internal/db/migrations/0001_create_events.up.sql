@@ -1,4 +1,5 @@
-create table events
+-- Databases created before the migrations already have this table
+create table if not exists events
 (
     id INTEGER not null constraint events_pk primary key,
     name TEXT not null,
@@ -0,0 +1 @@
+alter table events add column source TEXT not null DEFAULT 'zollhaus';
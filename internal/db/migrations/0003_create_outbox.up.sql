@@ -0,0 +1,15 @@
+create table outbox
+(
+    id INTEGER not null constraint outbox_pk primary key,
+    event_id INTEGER not null references events (id) ON DELETE CASCADE,
+    kind TEXT not null,
+    transport TEXT not null,
+    receiver TEXT not null,
+    revision TEXT not null,
+    attempts INTEGER not null DEFAULT 0,
+    last_error TEXT,
+    next_retry_at DATETIME not null,
+    delivered_at DATETIME,
+    created_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP,
+    constraint outbox_message unique (event_id, kind, transport, receiver, revision)
+);
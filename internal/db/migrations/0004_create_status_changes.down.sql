@@ -0,0 +1 @@
+drop table status_changes;
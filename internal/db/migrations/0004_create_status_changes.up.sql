@@ -0,0 +1,10 @@
+create table status_changes
+(
+    id INTEGER not null constraint status_changes_pk primary key,
+    event_id INTEGER not null references events (id) ON DELETE CASCADE,
+    old_status TEXT not null,
+    new_status TEXT not null,
+    kind TEXT not null,
+    announced_at DATETIME,
+    changed_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+drop table crawl_run_links;
+drop table crawl_runs;
+
+alter table events drop column removed_at;
+alter table events drop column missing_runs;
@@ -0,0 +1,17 @@
+alter table events add column missing_runs INTEGER not null DEFAULT 0;
+alter table events add column removed_at DATETIME;
+
+create table crawl_runs
+(
+    id INTEGER not null constraint crawl_runs_pk primary key,
+    source TEXT not null,
+    event_count INTEGER not null,
+    created_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
+
+create table crawl_run_links
+(
+    crawl_run_id INTEGER not null references crawl_runs (id) ON DELETE CASCADE,
+    link TEXT not null,
+    constraint crawl_run_links_pk primary key (crawl_run_id, link)
+);
@@ -0,0 +1,9 @@
+create table event_revisions
+(
+    id INTEGER not null constraint event_revisions_pk primary key,
+    event_id INTEGER not null references events (id) ON DELETE CASCADE,
+    field TEXT not null,
+    old_value TEXT not null,
+    new_value TEXT not null,
+    changed_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
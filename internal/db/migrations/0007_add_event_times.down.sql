@@ -0,0 +1,4 @@
+drop table event_postponements;
+
+alter table events drop column doors_at;
+alter table events drop column starts_at;
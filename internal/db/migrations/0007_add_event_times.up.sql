@@ -0,0 +1,11 @@
+alter table events add column starts_at DATETIME;
+alter table events add column doors_at DATETIME;
+
+create table event_postponements
+(
+    id INTEGER not null constraint event_postponements_pk primary key,
+    event_id INTEGER not null references events (id) ON DELETE CASCADE,
+    previous_date DATETIME not null,
+    new_date DATETIME not null,
+    created_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
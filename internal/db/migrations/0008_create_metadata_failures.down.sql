@@ -0,0 +1 @@
+drop table metadata_failures;
@@ -0,0 +1,8 @@
+create table metadata_failures
+(
+    id INTEGER not null constraint metadata_failures_pk primary key,
+    event_id INTEGER not null references events (id) ON DELETE CASCADE,
+    raw_response TEXT not null,
+    error TEXT not null,
+    created_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1 @@
+drop table lookup_cache;
@@ -0,0 +1,9 @@
+create table lookup_cache
+(
+    kind TEXT not null,
+    key TEXT not null,
+    value TEXT not null,
+    expires_at TIMESTAMP not null,
+    created_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP,
+    constraint lookup_cache_pk primary key (kind, key)
+);
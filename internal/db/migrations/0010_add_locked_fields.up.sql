@@ -0,0 +1 @@
+alter table events add column locked_fields TEXT not null default '';
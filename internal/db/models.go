@@ -9,6 +9,18 @@ import (
 	"time"
 )
 
+type CrawlRun struct {
+	ID         int64
+	Source     string
+	EventCount int64
+	CreatedAt  time.Time
+}
+
+type CrawlRunLink struct {
+	CrawlRunID int64
+	Link       string
+}
+
 type Event struct {
 	ID                 int64
 	Name               string
@@ -24,4 +36,67 @@ type Event struct {
 	ReportedAtUpcoming sql.NullTime
 	PostponedDate      sql.NullTime
 	CreatedAt          time.Time
+	Source             string
+	MissingRuns        int64
+	RemovedAt          sql.NullTime
+	StartsAt           sql.NullTime
+	DoorsAt            sql.NullTime
+	LockedFields       string
+}
+
+type EventPostponement struct {
+	ID           int64
+	EventID      int64
+	PreviousDate time.Time
+	NewDate      time.Time
+	CreatedAt    time.Time
+}
+
+type EventRevision struct {
+	ID        int64
+	EventID   int64
+	Field     string
+	OldValue  string
+	NewValue  string
+	ChangedAt time.Time
+}
+
+type LookupCache struct {
+	Kind      string
+	Key       string
+	Value     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type MetadataFailure struct {
+	ID          int64
+	EventID     int64
+	RawResponse string
+	Error       string
+	CreatedAt   time.Time
+}
+
+type Outbox struct {
+	ID          int64
+	EventID     int64
+	Kind        string
+	Transport   string
+	Receiver    string
+	Revision    string
+	Attempts    int64
+	LastError   sql.NullString
+	NextRetryAt time.Time
+	DeliveredAt sql.NullTime
+	CreatedAt   time.Time
+}
+
+type StatusChange struct {
+	ID          int64
+	EventID     int64
+	OldStatus   string
+	NewStatus   string
+	Kind        string
+	AnnouncedAt sql.NullTime
+	ChangedAt   time.Time
 }
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const OUTBOX_KIND_FRESH = "fresh"
+const OUTBOX_KIND_UPCOMING = "upcoming"
+const OUTBOX_KIND_STATUS = "status"
+const OUTBOX_KIND_DIGEST = "digest"
+
+// OutboxRepository holds the messages that still have to be delivered. A
+// message is only marked as reported on the event after a confirmed delivery.
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, message Outbox) error
+	GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]Outbox, error)
+	MarkDelivered(ctx context.Context, message Outbox, deliveredAt time.Time) error
+	MarkAllDelivered(ctx context.Context, messages []Outbox, deliveredAt time.Time) error
+	MarkFailed(ctx context.Context, message Outbox, sendErr error, nextRetryAt time.Time) error
+	Discard(ctx context.Context, message Outbox) error
+}
+
+func NewOutboxRepoFromConn(conn *sql.DB) *OutboxRepo {
+	return &OutboxRepo{Queries: New(conn), conn: conn}
+}
+
+type OutboxRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+}
+
+func (or *OutboxRepo) Enqueue(ctx context.Context, message Outbox) error {
+	return or.Queries.EnqueueMessage(ctx, EnqueueMessageParams{
+		EventID:     message.EventID,
+		Kind:        message.Kind,
+		Transport:   message.Transport,
+		Receiver:    message.Receiver,
+		Revision:    message.Revision,
+		NextRetryAt: message.NextRetryAt,
+	})
+}
+
+func (or *OutboxRepo) GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]Outbox, error) {
+	return or.Queries.GetDueMessages(ctx, GetDueMessagesParams{
+		MaxAttempts: int64(maxAttempts),
+		Now:         now,
+	})
+}
+
+// MarkDelivered stores the delivery and, once no message for the same event
+// revision is left, marks the event as reported in the same transaction.
+func (or *OutboxRepo) MarkDelivered(ctx context.Context, message Outbox, deliveredAt time.Time) error {
+	return or.MarkAllDelivered(ctx, []Outbox{message}, deliveredAt)
+}
+
+// MarkAllDelivered stores the deliveries of messages that were sent
+// together, like the events of a digest, in a single transaction.
+func (or *OutboxRepo) MarkAllDelivered(ctx context.Context, messages []Outbox, deliveredAt time.Time) error {
+	return MarkMessagesDelivered(ctx, or.conn, or.withTx, messages, deliveredAt)
+}
+
+func (or *OutboxRepo) withTx(tx *sql.Tx) OutboxStore {
+	return or.Queries.WithTx(tx)
+}
+
+// OutboxStore holds the queries MarkMessagesDelivered runs. Queries
+// implements it, the other database backends adapt their queries.
+type OutboxStore interface {
+	CountUndeliveredMessages(ctx context.Context, arg CountUndeliveredMessagesParams) (int64, error)
+	CreateEventRevision(ctx context.Context, arg CreateEventRevisionParams) error
+	GetEvent(ctx context.Context, id int64) (Event, error)
+	MarkFreshEventsAsReported(ctx context.Context, arg MarkFreshEventsAsReportedParams) error
+	MarkMessageDelivered(ctx context.Context, arg MarkMessageDeliveredParams) error
+	MarkStatusChangeAnnounced(ctx context.Context, arg MarkStatusChangeAnnouncedParams) error
+	MarkUpcomingEventsAsReported(ctx context.Context, arg MarkUpcomingEventsAsReportedParams) error
+}
+
+// MarkMessagesDelivered stores the deliveries in one transaction. Once no
+// message for the same event revision is left, the event is marked as
+// reported.
+func MarkMessagesDelivered(ctx context.Context, conn *sql.DB, withTx func(tx *sql.Tx) OutboxStore, messages []Outbox, deliveredAt time.Time) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := withTx(tx)
+
+	for _, message := range messages {
+		if err := markDelivered(ctx, queries, message, deliveredAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func markDelivered(ctx context.Context, queries OutboxStore, message Outbox, deliveredAt time.Time) error {
+	err := queries.MarkMessageDelivered(ctx, MarkMessageDeliveredParams{
+		DeliveredAt: sql.NullTime{Time: deliveredAt, Valid: true},
+		ID:          message.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	undelivered, err := queries.CountUndeliveredMessages(ctx, CountUndeliveredMessagesParams{
+		EventID:  message.EventID,
+		Kind:     message.Kind,
+		Revision: message.Revision,
+	})
+	if err != nil {
+		return err
+	}
+
+	if undelivered > 0 {
+		return nil
+	}
+
+	return markReported(ctx, queries, message, deliveredAt)
+}
+
+func markReported(ctx context.Context, queries OutboxStore, message Outbox, reportedAt time.Time) error {
+	switch message.Kind {
+	case OUTBOX_KIND_FRESH:
+		return trackRevisions(ctx, queries, message.EventID, func() error {
+			return queries.MarkFreshEventsAsReported(ctx, MarkFreshEventsAsReportedParams{
+				ReportedAtNew: sql.NullTime{Time: reportedAt, Valid: true},
+				ID:            message.EventID,
+			})
+		})
+	case OUTBOX_KIND_UPCOMING, OUTBOX_KIND_DIGEST:
+		return trackRevisions(ctx, queries, message.EventID, func() error {
+			return queries.MarkUpcomingEventsAsReported(ctx, MarkUpcomingEventsAsReportedParams{
+				ReportedAtUpcoming: sql.NullTime{Time: reportedAt, Valid: true},
+				ID:                 message.EventID,
+			})
+		})
+	case OUTBOX_KIND_STATUS:
+		id, err := ParseStatusRevision(message.Revision)
+		if err != nil {
+			return err
+		}
+
+		return queries.MarkStatusChangeAnnounced(ctx, MarkStatusChangeAnnouncedParams{
+			AnnouncedAt: sql.NullTime{Time: reportedAt, Valid: true},
+			ID:          id,
+		})
+	}
+
+	return nil
+}
+
+// DigestRevision identifies the messages of a digest in the outbox, every
+// event of the digest has one per destination.
+func DigestRevision(from time.Time, to time.Time) string {
+	return fmt.Sprintf("digest-%v/%v", from.Format(time.DateOnly), to.Format(time.DateOnly))
+}
+
+// ParseDigestRevision returns the window of the digest the revision belongs to.
+func ParseDigestRevision(revision string) (time.Time, time.Time, error) {
+	window, _ := strings.CutPrefix(revision, "digest-")
+	from, to, _ := strings.Cut(window, "/")
+
+	fromDate, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid digest revision [%v]: %w", revision, err)
+	}
+
+	toDate, err := time.ParseInLocation(time.DateOnly, to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid digest revision [%v]: %w", revision, err)
+	}
+
+	return fromDate, toDate, nil
+}
+
+// StatusRevision identifies the messages of a status change in the outbox.
+func StatusRevision(change StatusChange) string {
+	return fmt.Sprintf("status-%d", change.ID)
+}
+
+// ParseStatusRevision returns the id of the status change the revision belongs to.
+func ParseStatusRevision(revision string) (int64, error) {
+	var id int64
+	if _, err := fmt.Sscanf(revision, "status-%d", &id); err != nil {
+		return 0, fmt.Errorf("Invalid status revision [%v]: %w", revision, err)
+	}
+
+	return id, nil
+}
+
+func (or *OutboxRepo) MarkFailed(ctx context.Context, message Outbox, sendErr error, nextRetryAt time.Time) error {
+	return or.Queries.MarkMessageFailed(ctx, MarkMessageFailedParams{
+		LastError:   sql.NullString{String: sendErr.Error(), Valid: true},
+		NextRetryAt: nextRetryAt,
+		ID:          message.ID,
+	})
+}
+
+func (or *OutboxRepo) Discard(ctx context.Context, message Outbox) error {
+	return or.Queries.DeleteMessage(ctx, message.ID)
+}
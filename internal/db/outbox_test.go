@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareConnection(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	assert.Nil(t, err)
+
+	// Every connection would get its own in-memory database
+	conn.SetMaxOpenConns(1)
+
+	assert.Nil(t, Migrate(context.Background(), conn))
+
+	return conn
+}
+
+func TestOutboxRevisions(t *testing.T) {
+	t.Run("digest revisions hold the window of the digest", func(t *testing.T) {
+		from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+
+		windowFrom, windowTo, err := ParseDigestRevision(DigestRevision(from, from.AddDate(0, 0, 14)))
+		assert.Nil(t, err)
+		assert.Equal(t, from, windowFrom)
+		assert.Equal(t, from.AddDate(0, 0, 14), windowTo)
+
+		_, _, err = ParseDigestRevision("digest-2025-03-10")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("status revisions hold the id of the change", func(t *testing.T) {
+		id, err := ParseStatusRevision(StatusRevision(StatusChange{ID: 42}))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), id)
+
+		_, err = ParseStatusRevision("rev-1")
+		assert.NotNil(t, err)
+	})
+}
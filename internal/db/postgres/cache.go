@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+)
+
+func NewLookupCacheRepoFromConn(conn *sql.DB) *LookupCacheRepo {
+	return &LookupCacheRepo{Queries: New(conn), conn: conn}
+}
+
+// LookupCacheRepo is the Postgres counterpart of db.LookupCacheRepo.
+type LookupCacheRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+	// Ignore the stored entries, fresh answers still replace them
+	Refresh bool
+}
+
+// Get decodes the entry of the key into value and reports if there was an
+// entry that has not expired yet.
+func (lr *LookupCacheRepo) Get(ctx context.Context, kind string, key string, value any) (bool, error) {
+	if lr.Refresh {
+		return false, nil
+	}
+
+	raw, err := lr.Queries.GetLookup(ctx, GetLookupParams{
+		Kind: kind,
+		Key:  db.LookupKey(key),
+		Now:  time.Now(),
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal([]byte(raw), value); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (lr *LookupCacheRepo) Put(ctx context.Context, kind string, key string, value any, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return lr.Queries.PutLookup(ctx, PutLookupParams{
+		Kind:      kind,
+		Key:       db.LookupKey(key),
+		Value:     string(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+}
+
+// Prune deletes the expired entries.
+func (lr *LookupCacheRepo) Prune(ctx context.Context) error {
+	return lr.Queries.DeleteExpiredLookups(ctx, time.Now())
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+)
+
+func NewCrawlRunRepoFromConn(conn *sql.DB) *CrawlRunRepo {
+	return &CrawlRunRepo{Queries: New(conn), conn: conn}
+}
+
+// CrawlRunRepo is the Postgres counterpart of db.CrawlRunRepo.
+type CrawlRunRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+}
+
+// Record stores the run like db.CrawlRunRepo does.
+func (cr *CrawlRunRepo) Record(ctx context.Context, run db.CrawlRunResult) ([]db.Event, error) {
+	return db.RecordCrawlRun(ctx, cr.conn, cr.withTx, run)
+}
+
+func (cr *CrawlRunRepo) withTx(tx *sql.Tx) db.CrawlRunStore {
+	return crawlRunStore{eventStore{cr.Queries.WithTx(tx)}}
+}
+
+// crawlRunStore runs the queries of db.RecordCrawlRun.
+type crawlRunStore struct {
+	eventStore
+}
+
+func (store crawlRunStore) AddCrawlRunLink(ctx context.Context, arg db.AddCrawlRunLinkParams) error {
+	return store.queries.AddCrawlRunLink(ctx, AddCrawlRunLinkParams(arg))
+}
+
+func (store crawlRunStore) CreateCrawlRun(ctx context.Context, arg db.CreateCrawlRunParams) (int64, error) {
+	return store.queries.CreateCrawlRun(ctx, CreateCrawlRunParams(arg))
+}
+
+func (store crawlRunStore) GetMissingEvents(ctx context.Context, arg db.GetMissingEventsParams) ([]db.Event, error) {
+	return events(store.queries.GetMissingEvents(ctx, GetMissingEventsParams(arg)))
+}
+
+func (store crawlRunStore) GetReappearedEvents(ctx context.Context, arg db.GetReappearedEventsParams) ([]db.Event, error) {
+	return events(store.queries.GetReappearedEvents(ctx, GetReappearedEventsParams(arg)))
+}
+
+// GetUnseenEvents expects the time RecordCrawlRun passes, the SQLite query
+// leaves the type of the date open.
+func (store crawlRunStore) GetUnseenEvents(ctx context.Context, arg db.GetUnseenEventsParams) ([]db.Event, error) {
+	return events(store.queries.GetUnseenEvents(ctx, GetUnseenEventsParams{
+		Source:     arg.Source,
+		Date:       arg.Date.(time.Time),
+		CrawlRunID: arg.CrawlRunID,
+	}))
+}
+
+func (store crawlRunStore) IncrementMissingRuns(ctx context.Context, id int64) error {
+	return store.queries.IncrementMissingRuns(ctx, id)
+}
+
+func (store crawlRunStore) MarkEventRemoved(ctx context.Context, arg db.MarkEventRemovedParams) error {
+	return store.queries.MarkEventRemoved(ctx, MarkEventRemovedParams(arg))
+}
+
+func (store crawlRunStore) MarkEventSeen(ctx context.Context, id int64) error {
+	return store.queries.MarkEventSeen(ctx, id)
+}
@@ -0,0 +1,4 @@
+drop table event_postponements;
+drop table event_revisions;
+drop table status_changes;
+drop table events;
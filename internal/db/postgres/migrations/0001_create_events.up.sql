@@ -0,0 +1,52 @@
+create table events
+(
+    id BIGSERIAL not null constraint events_pk primary key,
+    name TEXT not null,
+    place TEXT not null,
+    status TEXT not null,
+    link TEXT UNIQUE not null,
+    date TIMESTAMPTZ not null,
+    artist TEXT,
+    category TEXT,
+    artist_url TEXT,
+    artist_img_url TEXT,
+    reported_at_new TIMESTAMPTZ,
+    reported_at_upcoming TIMESTAMPTZ,
+    postponed_date TIMESTAMPTZ,
+    created_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP,
+    source TEXT not null DEFAULT 'zollhaus',
+    missing_runs BIGINT not null DEFAULT 0,
+    removed_at TIMESTAMPTZ,
+    starts_at TIMESTAMPTZ,
+    doors_at TIMESTAMPTZ
+);
+
+create table status_changes
+(
+    id BIGSERIAL not null constraint status_changes_pk primary key,
+    event_id BIGINT not null references events (id) ON DELETE CASCADE,
+    old_status TEXT not null,
+    new_status TEXT not null,
+    kind TEXT not null,
+    announced_at TIMESTAMPTZ,
+    changed_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP
+);
+
+create table event_revisions
+(
+    id BIGSERIAL not null constraint event_revisions_pk primary key,
+    event_id BIGINT not null references events (id) ON DELETE CASCADE,
+    field TEXT not null,
+    old_value TEXT not null,
+    new_value TEXT not null,
+    changed_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP
+);
+
+create table event_postponements
+(
+    id BIGSERIAL not null constraint event_postponements_pk primary key,
+    event_id BIGINT not null references events (id) ON DELETE CASCADE,
+    previous_date TIMESTAMPTZ not null,
+    new_date TIMESTAMPTZ not null,
+    created_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+create table metadata_failures
+(
+    id BIGSERIAL not null constraint metadata_failures_pk primary key,
+    event_id BIGINT not null references events (id) ON DELETE CASCADE,
+    raw_response TEXT not null,
+    error TEXT not null,
+    created_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP
+);
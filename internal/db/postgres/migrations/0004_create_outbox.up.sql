@@ -0,0 +1,15 @@
+create table outbox
+(
+    id BIGSERIAL not null constraint outbox_pk primary key,
+    event_id BIGINT not null references events (id) ON DELETE CASCADE,
+    kind TEXT not null,
+    transport TEXT not null,
+    receiver TEXT not null,
+    revision TEXT not null,
+    attempts BIGINT not null DEFAULT 0,
+    last_error TEXT,
+    next_retry_at TIMESTAMPTZ not null,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP,
+    constraint outbox_message unique (event_id, kind, transport, receiver, revision)
+);
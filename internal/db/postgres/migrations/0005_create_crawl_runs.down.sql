@@ -0,0 +1,2 @@
+drop table crawl_run_links;
+drop table crawl_runs;
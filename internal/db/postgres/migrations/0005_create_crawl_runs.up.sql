@@ -0,0 +1,14 @@
+create table crawl_runs
+(
+    id BIGSERIAL not null constraint crawl_runs_pk primary key,
+    source TEXT not null,
+    event_count BIGINT not null,
+    created_at TIMESTAMPTZ not null DEFAULT CURRENT_TIMESTAMP
+);
+
+create table crawl_run_links
+(
+    crawl_run_id BIGINT not null references crawl_runs (id) ON DELETE CASCADE,
+    link TEXT not null,
+    constraint crawl_run_links_pk primary key (crawl_run_id, link)
+);
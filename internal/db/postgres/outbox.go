@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+)
+
+func NewOutboxRepoFromConn(conn *sql.DB) *OutboxRepo {
+	return &OutboxRepo{Queries: New(conn), conn: conn}
+}
+
+// OutboxRepo is the Postgres counterpart of db.OutboxRepo.
+type OutboxRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+}
+
+func (or *OutboxRepo) Enqueue(ctx context.Context, message db.Outbox) error {
+	return or.Queries.EnqueueMessage(ctx, EnqueueMessageParams{
+		EventID:     message.EventID,
+		Kind:        message.Kind,
+		Transport:   message.Transport,
+		Receiver:    message.Receiver,
+		Revision:    message.Revision,
+		NextRetryAt: message.NextRetryAt,
+	})
+}
+
+func (or *OutboxRepo) GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]db.Outbox, error) {
+	messages, err := or.Queries.GetDueMessages(ctx, GetDueMessagesParams{
+		MaxAttempts: int64(maxAttempts),
+		Now:         now,
+	})
+
+	return lo.Map(messages, func(message Outbox, _ int) db.Outbox {
+		return db.Outbox(message)
+	}), err
+}
+
+// MarkDelivered stores the delivery like db.OutboxRepo does.
+func (or *OutboxRepo) MarkDelivered(ctx context.Context, message db.Outbox, deliveredAt time.Time) error {
+	return or.MarkAllDelivered(ctx, []db.Outbox{message}, deliveredAt)
+}
+
+func (or *OutboxRepo) MarkAllDelivered(ctx context.Context, messages []db.Outbox, deliveredAt time.Time) error {
+	return db.MarkMessagesDelivered(ctx, or.conn, or.withTx, messages, deliveredAt)
+}
+
+func (or *OutboxRepo) MarkFailed(ctx context.Context, message db.Outbox, sendErr error, nextRetryAt time.Time) error {
+	return or.Queries.MarkMessageFailed(ctx, MarkMessageFailedParams{
+		LastError:   sql.NullString{String: sendErr.Error(), Valid: true},
+		NextRetryAt: nextRetryAt,
+		ID:          message.ID,
+	})
+}
+
+func (or *OutboxRepo) Discard(ctx context.Context, message db.Outbox) error {
+	return or.Queries.DeleteMessage(ctx, message.ID)
+}
+
+func (or *OutboxRepo) withTx(tx *sql.Tx) db.OutboxStore {
+	return outboxStore{eventStore{or.Queries.WithTx(tx)}}
+}
+
+// outboxStore runs the queries of db.MarkMessagesDelivered.
+type outboxStore struct {
+	eventStore
+}
+
+func (store outboxStore) CountUndeliveredMessages(ctx context.Context, arg db.CountUndeliveredMessagesParams) (int64, error) {
+	return store.queries.CountUndeliveredMessages(ctx, CountUndeliveredMessagesParams(arg))
+}
+
+func (store outboxStore) MarkFreshEventsAsReported(ctx context.Context, arg db.MarkFreshEventsAsReportedParams) error {
+	return store.queries.MarkFreshEventsAsReported(ctx, MarkFreshEventsAsReportedParams(arg))
+}
+
+func (store outboxStore) MarkMessageDelivered(ctx context.Context, arg db.MarkMessageDeliveredParams) error {
+	return store.queries.MarkMessageDelivered(ctx, MarkMessageDeliveredParams(arg))
+}
+
+func (store outboxStore) MarkStatusChangeAnnounced(ctx context.Context, arg db.MarkStatusChangeAnnouncedParams) error {
+	return store.queries.MarkStatusChangeAnnounced(ctx, MarkStatusChangeAnnouncedParams(arg))
+}
+
+func (store outboxStore) MarkUpcomingEventsAsReported(ctx context.Context, arg db.MarkUpcomingEventsAsReportedParams) error {
+	return store.queries.MarkUpcomingEventsAsReported(ctx, MarkUpcomingEventsAsReportedParams(arg))
+}
@@ -0,0 +1,168 @@
+-- name: GetEvent :one
+SELECT * FROM events WHERE id = $1 LIMIT 1;
+
+-- name: GetEventByLink :one
+SELECT * FROM events WHERE link = $1 LIMIT 1;
+
+-- name: GetEventsForPeriod :many
+SELECT * FROM events
+    WHERE reported_at_upcoming IS NULL
+    AND removed_at IS NULL
+    AND date::date >= sqlc.arg(from_date)::timestamptz::date
+    AND date::date <= sqlc.arg(to_date)::timestamptz::date
+ORDER BY date;
+
+-- name: GetEventsBetween :many
+SELECT * FROM events
+    WHERE date::date >= sqlc.arg(from_date)::timestamptz::date AND date::date <= sqlc.arg(to_date)::timestamptz::date
+ORDER BY date;
+
+-- name: GetFreshEvents :many
+SELECT * FROM events WHERE reported_at_new IS NULL AND removed_at IS NULL ORDER BY date;
+
+-- name: GetNakedEvents :many
+SELECT * FROM events WHERE reported_at_upcoming IS NULL AND (
+    (artist IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist,%')
+    OR (category IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,category,%')
+    OR (artist_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_url,%')
+    OR (artist_img_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_img_url,%')
+) ORDER BY date;
+
+-- name: MarkUpcomingEventsAsReported :exec
+UPDATE events SET reported_at_upcoming = $1 WHERE id = $2;
+
+-- name: UpdateEvent :exec
+UPDATE events
+SET
+    name = $1,
+    place = $2,
+    status = $3,
+    link = $4,
+    date = $5,
+    artist = $6,
+    reported_at_new = $7,
+    reported_at_upcoming = $8,
+    category = $9,
+    artist_url = $10,
+    artist_img_url = $11,
+    postponed_date = $12,
+    starts_at = $13,
+    doors_at = $14,
+    locked_fields = $15
+WHERE id = $16;
+
+-- name: CreateEvent :exec
+INSERT INTO events (name, place, status, link, date, artist_img_url, source, starts_at, doors_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT(link) DO UPDATE SET
+    name = excluded.name,
+    place = excluded.place,
+    status = excluded.status,
+    date = excluded.date;
+
+-- name: CreateStatusChange :exec
+INSERT INTO status_changes (event_id, old_status, new_status, kind) VALUES ($1, $2, $3, $4);
+
+-- name: GetStatusChange :one
+SELECT * FROM status_changes WHERE id = $1;
+
+-- name: GetStatusChanges :many
+SELECT * FROM status_changes WHERE event_id = $1 ORDER BY id;
+
+-- name: GetUnannouncedStatusChanges :many
+SELECT status_changes.* FROM status_changes
+JOIN events ON events.id = status_changes.event_id
+WHERE status_changes.announced_at IS NULL AND status_changes.kind != '' AND events.date::date >= sqlc.arg(from_date)::timestamptz::date
+ORDER BY status_changes.id;
+
+-- name: CreateEventRevision :exec
+INSERT INTO event_revisions (event_id, field, old_value, new_value) VALUES ($1, $2, $3, $4);
+
+-- name: GetEventRevisions :many
+SELECT * FROM event_revisions WHERE event_id = $1 ORDER BY id;
+
+-- name: CreatePostponement :exec
+INSERT INTO event_postponements (event_id, previous_date, new_date) VALUES ($1, $2, $3);
+
+-- name: GetPostponements :many
+SELECT * FROM event_postponements WHERE event_id = $1 ORDER BY id;
+
+-- name: CreateMetadataFailure :exec
+INSERT INTO metadata_failures (event_id, raw_response, error) VALUES ($1, $2, $3);
+
+-- name: GetMetadataFailures :many
+SELECT * FROM metadata_failures WHERE event_id = $1 ORDER BY id;
+
+-- name: MarkFreshEventsAsReported :exec
+UPDATE events SET reported_at_new = $1 WHERE id = $2;
+
+-- name: EnqueueMessage :exec
+INSERT INTO outbox (event_id, kind, transport, receiver, revision, next_retry_at) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT(event_id, kind, transport, receiver, revision) DO NOTHING;
+
+-- name: GetDueMessages :many
+SELECT * FROM outbox
+    WHERE delivered_at IS NULL
+    AND attempts < sqlc.arg(max_attempts)
+    AND next_retry_at <= sqlc.arg(now)
+ORDER BY id;
+
+-- name: MarkMessageDelivered :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = NULL, delivered_at = $1 WHERE id = $2;
+
+-- name: MarkMessageFailed :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_retry_at = $2 WHERE id = $3;
+
+-- name: DeleteMessage :exec
+DELETE FROM outbox WHERE id = $1;
+
+-- name: CountUndeliveredMessages :one
+SELECT COUNT(*) FROM outbox WHERE event_id = $1 AND kind = $2 AND revision = $3 AND delivered_at IS NULL;
+
+-- name: MarkStatusChangeAnnounced :exec
+UPDATE status_changes SET announced_at = $1 WHERE id = $2;
+
+-- name: CreateCrawlRun :one
+INSERT INTO crawl_runs (source, event_count) VALUES ($1, $2) RETURNING id;
+
+-- name: AddCrawlRunLink :exec
+INSERT INTO crawl_run_links (crawl_run_id, link) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: GetReappearedEvents :many
+SELECT * FROM events
+WHERE source = $1 AND (missing_runs > 0 OR removed_at IS NOT NULL)
+AND link IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = $2)
+ORDER BY date;
+
+-- name: MarkEventSeen :exec
+UPDATE events SET missing_runs = 0, removed_at = NULL WHERE id = $1;
+
+-- name: GetUnseenEvents :many
+SELECT * FROM events
+WHERE source = sqlc.arg(source) AND removed_at IS NULL AND date::date >= sqlc.arg(date)::timestamptz::date
+AND link NOT IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = sqlc.arg(crawl_run_id))
+ORDER BY date;
+
+-- name: IncrementMissingRuns :exec
+UPDATE events SET missing_runs = missing_runs + 1 WHERE id = $1;
+
+-- name: GetMissingEvents :many
+SELECT * FROM events WHERE source = $1 AND removed_at IS NULL AND missing_runs >= $2 ORDER BY date;
+
+-- name: MarkEventRemoved :exec
+UPDATE events SET removed_at = $1 WHERE id = $2;
+
+-- name: GetLookup :one
+SELECT value FROM lookup_cache
+    WHERE kind = sqlc.arg(kind)
+    AND key = sqlc.arg(key)
+    AND expires_at > sqlc.arg(now);
+
+-- name: PutLookup :exec
+INSERT INTO lookup_cache (kind, key, value, expires_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT(kind, key) DO UPDATE SET
+    value = excluded.value,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteExpiredLookups :exec
+DELETE FROM lookup_cache WHERE expires_at <= $1;
@@ -0,0 +1,215 @@
+// Package postgres implements the repositories on PostgreSQL, for
+// deployments that run next to other services using Postgres.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/samber/lo"
+
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Open connects to the database of the dsn and applies pending migrations.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	conn, err := sql.Open(db.DIALECT_POSTGRES, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func NewMigrator(conn *sql.DB) (*db.Migrator, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := db.ReadMigrations(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.NewMigratorWithMigrations(conn, db.DIALECT_POSTGRES, migrations), nil
+}
+
+// Migrate applies every pending migration.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	migrator, err := NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(ctx)
+
+	return err
+}
+
+func NewEventRepoFromConn(conn *sql.DB) *EventRepo {
+	return &EventRepo{Queries: New(conn), conn: conn}
+}
+
+// EventRepo is the Postgres counterpart of db.EventRepo.
+type EventRepo struct {
+	Queries *Queries
+	conn    *sql.DB
+}
+
+func (er *EventRepo) GetById(ctx context.Context, id int64) (db.Event, error) {
+	event, err := er.Queries.GetEvent(ctx, id)
+
+	return db.Event(event), err
+}
+
+func (er *EventRepo) GetByLink(ctx context.Context, link string) (db.Event, error) {
+	event, err := er.Queries.GetEventByLink(ctx, link)
+
+	return db.Event(event), err
+}
+
+func (er *EventRepo) GetEventsBetween(ctx context.Context, fromDate time.Time, toDate time.Time) ([]db.Event, error) {
+	return events(er.Queries.GetEventsBetween(ctx, GetEventsBetweenParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+	}))
+}
+
+func (er *EventRepo) GetFreshEvents(ctx context.Context) ([]db.Event, error) {
+	return events(er.Queries.GetFreshEvents(ctx))
+}
+
+func (er *EventRepo) GetNakedEvents(ctx context.Context) ([]db.Event, error) {
+	return events(er.Queries.GetNakedEvents(ctx))
+}
+
+func (er *EventRepo) GetUpcomingEvents(ctx context.Context, fromDate time.Time, daysAhead int) ([]db.Event, error) {
+	nm := fromDate.AddDate(0, 0, daysAhead)
+	startOfMonth := time.Date(nm.Year(), nm.Month(), 1, 0, 0, 0, 0, time.Local)
+	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
+
+	return events(er.Queries.GetEventsForPeriod(ctx, GetEventsForPeriodParams{
+		FromDate: fromDate,
+		ToDate:   endOfMonth,
+	}))
+}
+
+// GetPostponements returns the previous dates of the event, oldest first.
+func (er *EventRepo) GetPostponements(ctx context.Context, eventId int64) ([]db.EventPostponement, error) {
+	postponements, err := er.Queries.GetPostponements(ctx, eventId)
+
+	return lo.Map(postponements, func(postponement EventPostponement, _ int) db.EventPostponement {
+		return db.EventPostponement(postponement)
+	}), err
+}
+
+// GetMetadataFailures returns the responses the metadata of the event
+// couldn't be read from, oldest first.
+func (er *EventRepo) GetMetadataFailures(ctx context.Context, eventId int64) ([]db.MetadataFailure, error) {
+	failures, err := er.Queries.GetMetadataFailures(ctx, eventId)
+
+	return lo.Map(failures, func(failure MetadataFailure, _ int) db.MetadataFailure {
+		return db.MetadataFailure(failure)
+	}), err
+}
+
+// GetRevisions returns the changes of the event, oldest first.
+func (er *EventRepo) GetRevisions(ctx context.Context, eventId int64) ([]db.EventRevision, error) {
+	revisions, err := er.Queries.GetEventRevisions(ctx, eventId)
+
+	return lo.Map(revisions, func(revision EventRevision, _ int) db.EventRevision {
+		return db.EventRevision(revision)
+	}), err
+}
+
+// RecordMetadataFailure keeps the raw response the metadata of the event
+// couldn't be read from.
+func (er *EventRepo) RecordMetadataFailure(ctx context.Context, eventId int64, rawResponse string, reason string) error {
+	return er.Queries.CreateMetadataFailure(ctx, CreateMetadataFailureParams{
+		EventID:     eventId,
+		RawResponse: rawResponse,
+		Error:       reason,
+	})
+}
+
+func (er *EventRepo) GetStatusChange(ctx context.Context, id int64) (db.StatusChange, error) {
+	change, err := er.Queries.GetStatusChange(ctx, id)
+
+	return db.StatusChange(change), err
+}
+
+// GetUnannouncedStatusChanges returns the status changes worth announcing of
+// the events from fromDate on, that weren't announced yet.
+func (er *EventRepo) GetUnannouncedStatusChanges(ctx context.Context, fromDate time.Time) ([]db.StatusChange, error) {
+	changes, err := er.Queries.GetUnannouncedStatusChanges(ctx, fromDate)
+
+	return lo.Map(changes, func(change StatusChange, _ int) db.StatusChange {
+		return db.StatusChange(change)
+	}), err
+}
+
+// Save creates or updates the event like db.EventRepo does.
+func (er *EventRepo) Save(ctx context.Context, event db.Event) error {
+	return db.SaveEvent(ctx, er.conn, er.withTx, event)
+}
+
+func (er *EventRepo) withTx(tx *sql.Tx) db.EventStore {
+	return eventStore{er.Queries.WithTx(tx)}
+}
+
+// eventStore runs the queries of db.SaveEvent, the parameters and models of
+// both backends are identical.
+type eventStore struct {
+	queries *Queries
+}
+
+func (es eventStore) CreateEvent(ctx context.Context, arg db.CreateEventParams) error {
+	return es.queries.CreateEvent(ctx, CreateEventParams(arg))
+}
+
+func (es eventStore) CreateEventRevision(ctx context.Context, arg db.CreateEventRevisionParams) error {
+	return es.queries.CreateEventRevision(ctx, CreateEventRevisionParams(arg))
+}
+
+func (es eventStore) CreatePostponement(ctx context.Context, arg db.CreatePostponementParams) error {
+	return es.queries.CreatePostponement(ctx, CreatePostponementParams(arg))
+}
+
+func (es eventStore) CreateStatusChange(ctx context.Context, arg db.CreateStatusChangeParams) error {
+	return es.queries.CreateStatusChange(ctx, CreateStatusChangeParams(arg))
+}
+
+func (es eventStore) GetEvent(ctx context.Context, id int64) (db.Event, error) {
+	event, err := es.queries.GetEvent(ctx, id)
+
+	return db.Event(event), err
+}
+
+func (es eventStore) GetEventByLink(ctx context.Context, link string) (db.Event, error) {
+	event, err := es.queries.GetEventByLink(ctx, link)
+
+	return db.Event(event), err
+}
+
+func (es eventStore) UpdateEvent(ctx context.Context, arg db.UpdateEventParams) error {
+	return es.queries.UpdateEvent(ctx, UpdateEventParams(arg))
+}
+
+func events(events []Event, err error) ([]db.Event, error) {
+	return lo.Map(events, func(event Event, _ int) db.Event {
+		return db.Event(event)
+	}), err
+}
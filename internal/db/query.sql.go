@@ -11,6 +11,20 @@ import (
 	"time"
 )
 
+const addCrawlRunLink = `-- name: AddCrawlRunLink :exec
+INSERT OR IGNORE INTO crawl_run_links (crawl_run_id, link) VALUES (?, ?)
+`
+
+type AddCrawlRunLinkParams struct {
+	CrawlRunID int64
+	Link       string
+}
+
+func (q *Queries) AddCrawlRunLink(ctx context.Context, arg AddCrawlRunLinkParams) error {
+	_, err := q.db.ExecContext(ctx, addCrawlRunLink, arg.CrawlRunID, arg.Link)
+	return err
+}
+
 const addMetaData = `-- name: AddMetaData :exec
 UPDATE events SET artist = ?, category = ?, artist_url = ?, artist_img_url = ? WHERE id = ?
 `
@@ -34,8 +48,42 @@ func (q *Queries) AddMetaData(ctx context.Context, arg AddMetaDataParams) error
 	return err
 }
 
+const countUndeliveredMessages = `-- name: CountUndeliveredMessages :one
+SELECT COUNT(*) FROM outbox WHERE event_id = ? AND kind = ? AND revision = ? AND delivered_at IS NULL
+`
+
+type CountUndeliveredMessagesParams struct {
+	EventID  int64
+	Kind     string
+	Revision string
+}
+
+func (q *Queries) CountUndeliveredMessages(ctx context.Context, arg CountUndeliveredMessagesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUndeliveredMessages, arg.EventID, arg.Kind, arg.Revision)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCrawlRun = `-- name: CreateCrawlRun :execlastid
+INSERT INTO crawl_runs (source, event_count) VALUES (?, ?)
+`
+
+type CreateCrawlRunParams struct {
+	Source     string
+	EventCount int64
+}
+
+func (q *Queries) CreateCrawlRun(ctx context.Context, arg CreateCrawlRunParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createCrawlRun, arg.Source, arg.EventCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
 const createEvent = `-- name: CreateEvent :exec
-INSERT INTO events (name, place, status, link, date, artist_img_url) VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO events (name, place, status, link, date, artist_img_url, source, starts_at, doors_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(link) DO UPDATE SET
     name = excluded.name,
     place = excluded.place,
@@ -50,6 +98,9 @@ type CreateEventParams struct {
 	Link         string
 	Date         time.Time
 	ArtistImgUrl sql.NullString
+	Source       string
+	StartsAt     sql.NullTime
+	DoorsAt      sql.NullTime
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error {
@@ -60,12 +111,179 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error
 		arg.Link,
 		arg.Date,
 		arg.ArtistImgUrl,
+		arg.Source,
+		arg.StartsAt,
+		arg.DoorsAt,
+	)
+	return err
+}
+
+const createEventRevision = `-- name: CreateEventRevision :exec
+INSERT INTO event_revisions (event_id, field, old_value, new_value) VALUES (?, ?, ?, ?)
+`
+
+type CreateEventRevisionParams struct {
+	EventID  int64
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+func (q *Queries) CreateEventRevision(ctx context.Context, arg CreateEventRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createEventRevision,
+		arg.EventID,
+		arg.Field,
+		arg.OldValue,
+		arg.NewValue,
+	)
+	return err
+}
+
+const createMetadataFailure = `-- name: CreateMetadataFailure :exec
+INSERT INTO metadata_failures (event_id, raw_response, error) VALUES (?, ?, ?)
+`
+
+type CreateMetadataFailureParams struct {
+	EventID     int64
+	RawResponse string
+	Error       string
+}
+
+func (q *Queries) CreateMetadataFailure(ctx context.Context, arg CreateMetadataFailureParams) error {
+	_, err := q.db.ExecContext(ctx, createMetadataFailure, arg.EventID, arg.RawResponse, arg.Error)
+	return err
+}
+
+const createPostponement = `-- name: CreatePostponement :exec
+INSERT INTO event_postponements (event_id, previous_date, new_date) VALUES (?, ?, ?)
+`
+
+type CreatePostponementParams struct {
+	EventID      int64
+	PreviousDate time.Time
+	NewDate      time.Time
+}
+
+func (q *Queries) CreatePostponement(ctx context.Context, arg CreatePostponementParams) error {
+	_, err := q.db.ExecContext(ctx, createPostponement, arg.EventID, arg.PreviousDate, arg.NewDate)
+	return err
+}
+
+const createStatusChange = `-- name: CreateStatusChange :exec
+INSERT INTO status_changes (event_id, old_status, new_status, kind) VALUES (?, ?, ?, ?)
+`
+
+type CreateStatusChangeParams struct {
+	EventID   int64
+	OldStatus string
+	NewStatus string
+	Kind      string
+}
+
+func (q *Queries) CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createStatusChange,
+		arg.EventID,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.Kind,
+	)
+	return err
+}
+
+const deleteExpiredLookups = `-- name: DeleteExpiredLookups :exec
+DELETE FROM lookup_cache WHERE datetime(expires_at) <= datetime(?)
+`
+
+func (q *Queries) DeleteExpiredLookups(ctx context.Context, datetime interface{}) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredLookups, datetime)
+	return err
+}
+
+const deleteMessage = `-- name: DeleteMessage :exec
+DELETE FROM outbox WHERE id = ?
+`
+
+func (q *Queries) DeleteMessage(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteMessage, id)
+	return err
+}
+
+const enqueueMessage = `-- name: EnqueueMessage :exec
+INSERT INTO outbox (event_id, kind, transport, receiver, revision, next_retry_at) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(event_id, kind, transport, receiver, revision) DO NOTHING
+`
+
+type EnqueueMessageParams struct {
+	EventID     int64
+	Kind        string
+	Transport   string
+	Receiver    string
+	Revision    string
+	NextRetryAt time.Time
+}
+
+func (q *Queries) EnqueueMessage(ctx context.Context, arg EnqueueMessageParams) error {
+	_, err := q.db.ExecContext(ctx, enqueueMessage,
+		arg.EventID,
+		arg.Kind,
+		arg.Transport,
+		arg.Receiver,
+		arg.Revision,
+		arg.NextRetryAt,
 	)
 	return err
 }
 
+const getDueMessages = `-- name: GetDueMessages :many
+SELECT id, event_id, kind, transport, receiver, revision, attempts, last_error, next_retry_at, delivered_at, created_at FROM outbox
+    WHERE delivered_at IS NULL
+    AND attempts < ?1
+    AND datetime(next_retry_at) <= datetime(?2)
+ORDER BY id
+`
+
+type GetDueMessagesParams struct {
+	MaxAttempts int64
+	Now         interface{}
+}
+
+func (q *Queries) GetDueMessages(ctx context.Context, arg GetDueMessagesParams) ([]Outbox, error) {
+	rows, err := q.db.QueryContext(ctx, getDueMessages, arg.MaxAttempts, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Kind,
+			&i.Transport,
+			&i.Receiver,
+			&i.Revision,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextRetryAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEvent = `-- name: GetEvent :one
-SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at FROM events WHERE id = ? LIMIT 1
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetEvent(ctx context.Context, id int64) (Event, error) {
@@ -86,12 +304,18 @@ func (q *Queries) GetEvent(ctx context.Context, id int64) (Event, error) {
 		&i.ReportedAtUpcoming,
 		&i.PostponedDate,
 		&i.CreatedAt,
+		&i.Source,
+		&i.MissingRuns,
+		&i.RemovedAt,
+		&i.StartsAt,
+		&i.DoorsAt,
+		&i.LockedFields,
 	)
 	return i, err
 }
 
 const getEventByLink = `-- name: GetEventByLink :one
-SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at FROM events WHERE link = ? LIMIT 1
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events WHERE link = ? LIMIT 1
 `
 
 func (q *Queries) GetEventByLink(ctx context.Context, link string) (Event, error) {
@@ -112,13 +336,109 @@ func (q *Queries) GetEventByLink(ctx context.Context, link string) (Event, error
 		&i.ReportedAtUpcoming,
 		&i.PostponedDate,
 		&i.CreatedAt,
+		&i.Source,
+		&i.MissingRuns,
+		&i.RemovedAt,
+		&i.StartsAt,
+		&i.DoorsAt,
+		&i.LockedFields,
 	)
 	return i, err
 }
 
+const getEventRevisions = `-- name: GetEventRevisions :many
+SELECT id, event_id, field, old_value, new_value, changed_at FROM event_revisions WHERE event_id = ? ORDER BY id
+`
+
+func (q *Queries) GetEventRevisions(ctx context.Context, eventID int64) ([]EventRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getEventRevisions, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventRevision
+	for rows.Next() {
+		var i EventRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Field,
+			&i.OldValue,
+			&i.NewValue,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsBetween = `-- name: GetEventsBetween :many
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events
+    WHERE DATE(date) >= DATE(?) AND DATE(date) <= DATE(?)
+ORDER BY date
+`
+
+type GetEventsBetweenParams struct {
+	Date   interface{}
+	Date_2 interface{}
+}
+
+func (q *Queries) GetEventsBetween(ctx context.Context, arg GetEventsBetweenParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsBetween, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Place,
+			&i.Status,
+			&i.Link,
+			&i.Date,
+			&i.Artist,
+			&i.Category,
+			&i.ArtistUrl,
+			&i.ArtistImgUrl,
+			&i.ReportedAtNew,
+			&i.ReportedAtUpcoming,
+			&i.PostponedDate,
+			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEventsForPeriod = `-- name: GetEventsForPeriod :many
-SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at FROM events
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events
     WHERE reported_at_upcoming IS NULL
+    AND removed_at IS NULL
     AND (
         DATE(date) >= DATE(?) AND DATE(date) <= DATE(?)
     )
@@ -154,6 +474,12 @@ func (q *Queries) GetEventsForPeriod(ctx context.Context, arg GetEventsForPeriod
 			&i.ReportedAtUpcoming,
 			&i.PostponedDate,
 			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
 		); err != nil {
 			return nil, err
 		}
@@ -169,7 +495,7 @@ func (q *Queries) GetEventsForPeriod(ctx context.Context, arg GetEventsForPeriod
 }
 
 const getFreshEvents = `-- name: GetFreshEvents :many
-SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at FROM events WHERE reported_at_new IS NULL ORDER BY date
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events WHERE reported_at_new IS NULL AND removed_at IS NULL ORDER BY date
 `
 
 func (q *Queries) GetFreshEvents(ctx context.Context) ([]Event, error) {
@@ -196,6 +522,118 @@ func (q *Queries) GetFreshEvents(ctx context.Context) ([]Event, error) {
 			&i.ReportedAtUpcoming,
 			&i.PostponedDate,
 			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLookup = `-- name: GetLookup :one
+SELECT value FROM lookup_cache
+    WHERE kind = ?1
+    AND key = ?2
+    AND datetime(expires_at) > datetime(?3)
+`
+
+type GetLookupParams struct {
+	Kind string
+	Key  string
+	Now  interface{}
+}
+
+func (q *Queries) GetLookup(ctx context.Context, arg GetLookupParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLookup, arg.Kind, arg.Key, arg.Now)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
+const getMetadataFailures = `-- name: GetMetadataFailures :many
+SELECT id, event_id, raw_response, error, created_at FROM metadata_failures WHERE event_id = ? ORDER BY id
+`
+
+func (q *Queries) GetMetadataFailures(ctx context.Context, eventID int64) ([]MetadataFailure, error) {
+	rows, err := q.db.QueryContext(ctx, getMetadataFailures, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MetadataFailure
+	for rows.Next() {
+		var i MetadataFailure
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.RawResponse,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissingEvents = `-- name: GetMissingEvents :many
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events WHERE source = ? AND removed_at IS NULL AND missing_runs >= ? ORDER BY date
+`
+
+type GetMissingEventsParams struct {
+	Source      string
+	MissingRuns int64
+}
+
+func (q *Queries) GetMissingEvents(ctx context.Context, arg GetMissingEventsParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getMissingEvents, arg.Source, arg.MissingRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Place,
+			&i.Status,
+			&i.Link,
+			&i.Date,
+			&i.Artist,
+			&i.Category,
+			&i.ArtistUrl,
+			&i.ArtistImgUrl,
+			&i.ReportedAtNew,
+			&i.ReportedAtUpcoming,
+			&i.PostponedDate,
+			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
 		); err != nil {
 			return nil, err
 		}
@@ -211,11 +649,11 @@ func (q *Queries) GetFreshEvents(ctx context.Context) ([]Event, error) {
 }
 
 const getNakedEvents = `-- name: GetNakedEvents :many
-SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at FROM events WHERE reported_at_upcoming IS NULL AND (
-    artist IS NULL
-    OR category IS NULL
-    OR artist_url IS NULL
-    OR artist_img_url IS NULL
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events WHERE reported_at_upcoming IS NULL AND (
+    (artist IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist,%')
+    OR (category IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,category,%')
+    OR (artist_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_url,%')
+    OR (artist_img_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_img_url,%')
 ) ORDER BY date
 `
 
@@ -243,6 +681,250 @@ func (q *Queries) GetNakedEvents(ctx context.Context) ([]Event, error) {
 			&i.ReportedAtUpcoming,
 			&i.PostponedDate,
 			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostponements = `-- name: GetPostponements :many
+SELECT id, event_id, previous_date, new_date, created_at FROM event_postponements WHERE event_id = ? ORDER BY id
+`
+
+func (q *Queries) GetPostponements(ctx context.Context, eventID int64) ([]EventPostponement, error) {
+	rows, err := q.db.QueryContext(ctx, getPostponements, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventPostponement
+	for rows.Next() {
+		var i EventPostponement
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.PreviousDate,
+			&i.NewDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReappearedEvents = `-- name: GetReappearedEvents :many
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events
+WHERE source = ? AND (missing_runs > 0 OR removed_at IS NOT NULL)
+AND link IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = ?)
+ORDER BY date
+`
+
+type GetReappearedEventsParams struct {
+	Source     string
+	CrawlRunID int64
+}
+
+func (q *Queries) GetReappearedEvents(ctx context.Context, arg GetReappearedEventsParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getReappearedEvents, arg.Source, arg.CrawlRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Place,
+			&i.Status,
+			&i.Link,
+			&i.Date,
+			&i.Artist,
+			&i.Category,
+			&i.ArtistUrl,
+			&i.ArtistImgUrl,
+			&i.ReportedAtNew,
+			&i.ReportedAtUpcoming,
+			&i.PostponedDate,
+			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStatusChange = `-- name: GetStatusChange :one
+SELECT id, event_id, old_status, new_status, kind, announced_at, changed_at FROM status_changes WHERE id = ?
+`
+
+func (q *Queries) GetStatusChange(ctx context.Context, id int64) (StatusChange, error) {
+	row := q.db.QueryRowContext(ctx, getStatusChange, id)
+	var i StatusChange
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.OldStatus,
+		&i.NewStatus,
+		&i.Kind,
+		&i.AnnouncedAt,
+		&i.ChangedAt,
+	)
+	return i, err
+}
+
+const getStatusChanges = `-- name: GetStatusChanges :many
+SELECT id, event_id, old_status, new_status, kind, announced_at, changed_at FROM status_changes WHERE event_id = ? ORDER BY id
+`
+
+func (q *Queries) GetStatusChanges(ctx context.Context, eventID int64) ([]StatusChange, error) {
+	rows, err := q.db.QueryContext(ctx, getStatusChanges, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StatusChange
+	for rows.Next() {
+		var i StatusChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Kind,
+			&i.AnnouncedAt,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnannouncedStatusChanges = `-- name: GetUnannouncedStatusChanges :many
+SELECT status_changes.id, status_changes.event_id, status_changes.old_status, status_changes.new_status, status_changes.kind, status_changes.announced_at, status_changes.changed_at FROM status_changes
+JOIN events ON events.id = status_changes.event_id
+WHERE status_changes.announced_at IS NULL AND status_changes.kind != '' AND DATE(events.date) >= DATE(?)
+ORDER BY status_changes.id
+`
+
+func (q *Queries) GetUnannouncedStatusChanges(ctx context.Context, date interface{}) ([]StatusChange, error) {
+	rows, err := q.db.QueryContext(ctx, getUnannouncedStatusChanges, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StatusChange
+	for rows.Next() {
+		var i StatusChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Kind,
+			&i.AnnouncedAt,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnseenEvents = `-- name: GetUnseenEvents :many
+SELECT id, name, place, status, link, date, artist, category, artist_url, artist_img_url, reported_at_new, reported_at_upcoming, postponed_date, created_at, source, missing_runs, removed_at, starts_at, doors_at, locked_fields FROM events
+WHERE source = ? AND removed_at IS NULL AND DATE(date) >= DATE(?)
+AND link NOT IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = ?)
+ORDER BY date
+`
+
+type GetUnseenEventsParams struct {
+	Source     string
+	Date       interface{}
+	CrawlRunID int64
+}
+
+func (q *Queries) GetUnseenEvents(ctx context.Context, arg GetUnseenEventsParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getUnseenEvents, arg.Source, arg.Date, arg.CrawlRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Place,
+			&i.Status,
+			&i.Link,
+			&i.Date,
+			&i.Artist,
+			&i.Category,
+			&i.ArtistUrl,
+			&i.ArtistImgUrl,
+			&i.ReportedAtNew,
+			&i.ReportedAtUpcoming,
+			&i.PostponedDate,
+			&i.CreatedAt,
+			&i.Source,
+			&i.MissingRuns,
+			&i.RemovedAt,
+			&i.StartsAt,
+			&i.DoorsAt,
+			&i.LockedFields,
 		); err != nil {
 			return nil, err
 		}
@@ -257,6 +939,38 @@ func (q *Queries) GetNakedEvents(ctx context.Context) ([]Event, error) {
 	return items, nil
 }
 
+const incrementMissingRuns = `-- name: IncrementMissingRuns :exec
+UPDATE events SET missing_runs = missing_runs + 1 WHERE id = ?
+`
+
+func (q *Queries) IncrementMissingRuns(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, incrementMissingRuns, id)
+	return err
+}
+
+const markEventRemoved = `-- name: MarkEventRemoved :exec
+UPDATE events SET removed_at = ? WHERE id = ?
+`
+
+type MarkEventRemovedParams struct {
+	RemovedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) MarkEventRemoved(ctx context.Context, arg MarkEventRemovedParams) error {
+	_, err := q.db.ExecContext(ctx, markEventRemoved, arg.RemovedAt, arg.ID)
+	return err
+}
+
+const markEventSeen = `-- name: MarkEventSeen :exec
+UPDATE events SET missing_runs = 0, removed_at = NULL WHERE id = ?
+`
+
+func (q *Queries) MarkEventSeen(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markEventSeen, id)
+	return err
+}
+
 const markFreshEventsAsReported = `-- name: MarkFreshEventsAsReported :exec
 UPDATE events SET reported_at_new = ? WHERE id = ?
 `
@@ -271,6 +985,49 @@ func (q *Queries) MarkFreshEventsAsReported(ctx context.Context, arg MarkFreshEv
 	return err
 }
 
+const markMessageDelivered = `-- name: MarkMessageDelivered :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = NULL, delivered_at = ? WHERE id = ?
+`
+
+type MarkMessageDeliveredParams struct {
+	DeliveredAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) MarkMessageDelivered(ctx context.Context, arg MarkMessageDeliveredParams) error {
+	_, err := q.db.ExecContext(ctx, markMessageDelivered, arg.DeliveredAt, arg.ID)
+	return err
+}
+
+const markMessageFailed = `-- name: MarkMessageFailed :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_retry_at = ? WHERE id = ?
+`
+
+type MarkMessageFailedParams struct {
+	LastError   sql.NullString
+	NextRetryAt time.Time
+	ID          int64
+}
+
+func (q *Queries) MarkMessageFailed(ctx context.Context, arg MarkMessageFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markMessageFailed, arg.LastError, arg.NextRetryAt, arg.ID)
+	return err
+}
+
+const markStatusChangeAnnounced = `-- name: MarkStatusChangeAnnounced :exec
+UPDATE status_changes SET announced_at = ? WHERE id = ?
+`
+
+type MarkStatusChangeAnnouncedParams struct {
+	AnnouncedAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) MarkStatusChangeAnnounced(ctx context.Context, arg MarkStatusChangeAnnouncedParams) error {
+	_, err := q.db.ExecContext(ctx, markStatusChangeAnnounced, arg.AnnouncedAt, arg.ID)
+	return err
+}
+
 const markUpcomingEventsAsReported = `-- name: MarkUpcomingEventsAsReported :exec
 UPDATE events SET reported_at_upcoming = ? WHERE id = ?
 `
@@ -285,6 +1042,31 @@ func (q *Queries) MarkUpcomingEventsAsReported(ctx context.Context, arg MarkUpco
 	return err
 }
 
+const putLookup = `-- name: PutLookup :exec
+INSERT INTO lookup_cache (kind, key, value, expires_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(kind, key) DO UPDATE SET
+    value = excluded.value,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP
+`
+
+type PutLookupParams struct {
+	Kind      string
+	Key       string
+	Value     string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) PutLookup(ctx context.Context, arg PutLookupParams) error {
+	_, err := q.db.ExecContext(ctx, putLookup,
+		arg.Kind,
+		arg.Key,
+		arg.Value,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
 const updateEvent = `-- name: UpdateEvent :exec
 UPDATE events
 SET
@@ -299,7 +1081,10 @@ SET
     category = ?,
     artist_url = ?,
     artist_img_url = ?,
-    postponed_date = ?
+    postponed_date = ?,
+    starts_at = ?,
+    doors_at = ?,
+    locked_fields = ?
 WHERE id = ?
 `
 
@@ -316,6 +1101,9 @@ type UpdateEventParams struct {
 	ArtistUrl          sql.NullString
 	ArtistImgUrl       sql.NullString
 	PostponedDate      sql.NullTime
+	StartsAt           sql.NullTime
+	DoorsAt            sql.NullTime
+	LockedFields       string
 	ID                 int64
 }
 
@@ -333,6 +1121,9 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) error
 		arg.ArtistUrl,
 		arg.ArtistImgUrl,
 		arg.PostponedDate,
+		arg.StartsAt,
+		arg.DoorsAt,
+		arg.LockedFields,
 		arg.ID,
 	)
 	return err
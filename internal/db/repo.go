@@ -3,34 +3,44 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
 	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/status"
 )
 
 type EventRepository interface {
 	GetById(ctx context.Context, id int64) (Event, error)
 	GetByLink(ctx context.Context, link string) (Event, error)
+	GetEventsBetween(ctx context.Context, fromDate time.Time, toDate time.Time) ([]Event, error)
 	GetFreshEvents(ctx context.Context) ([]Event, error)
 	GetNakedEvents(ctx context.Context) ([]Event, error)
+	GetPostponements(ctx context.Context, eventId int64) ([]EventPostponement, error)
+	GetRevisions(ctx context.Context, eventId int64) ([]EventRevision, error)
+	GetStatusChange(ctx context.Context, id int64) (StatusChange, error)
 	GetUpcomingEvents(ctx context.Context, fromDate time.Time, daysAhead int) ([]Event, error)
+	GetUnannouncedStatusChanges(ctx context.Context, fromDate time.Time) ([]StatusChange, error)
 	Save(ctx context.Context, event Event) error
 }
 
 func NewEventRepoFromConn(conn *sql.DB) *EventRepo {
-	return &EventRepo{Queries: New(conn)}
+	return &EventRepo{Queries: New(conn), conn: conn}
 }
 
-func NewDbEventRepo() (*EventRepo, error) {
-	queries, err := NewQueries()
+func NewDbEventRepo(path string) (*EventRepo, error) {
+	conn, err := NewSqliteConn(path)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &EventRepo{queries}, nil
+	return NewEventRepoFromConn(conn), nil
 }
 
 type EventRepo struct {
 	Queries *Queries
+	conn    *sql.DB
 }
 
 func (er *EventRepo) GetById(ctx context.Context, id int64) (Event, error) {
@@ -41,6 +51,13 @@ func (er *EventRepo) GetByLink(ctx context.Context, link string) (Event, error)
 	return er.Queries.GetEventByLink(ctx, link)
 }
 
+func (er *EventRepo) GetEventsBetween(ctx context.Context, fromDate time.Time, toDate time.Time) ([]Event, error) {
+	return er.Queries.GetEventsBetween(ctx, GetEventsBetweenParams{
+		Date:   fromDate,
+		Date_2: toDate,
+	})
+}
+
 func (er *EventRepo) GetFreshEvents(ctx context.Context) ([]Event, error) {
 	return er.Queries.GetFreshEvents(ctx)
 }
@@ -60,19 +77,112 @@ func (er *EventRepo) GetUpcomingEvents(ctx context.Context, fromDate time.Time,
 	})
 }
 
+// GetPostponements returns the previous dates of the event, oldest first.
+func (er *EventRepo) GetPostponements(ctx context.Context, eventId int64) ([]EventPostponement, error) {
+	return er.Queries.GetPostponements(ctx, eventId)
+}
+
+// GetMetadataFailures returns the responses the metadata of the event
+// couldn't be read from, oldest first.
+func (er *EventRepo) GetMetadataFailures(ctx context.Context, eventId int64) ([]MetadataFailure, error) {
+	return er.Queries.GetMetadataFailures(ctx, eventId)
+}
+
+// GetRevisions returns the changes of the event, oldest first.
+func (er *EventRepo) GetRevisions(ctx context.Context, eventId int64) ([]EventRevision, error) {
+	return er.Queries.GetEventRevisions(ctx, eventId)
+}
+
+// RecordMetadataFailure keeps the raw response the metadata of the event
+// couldn't be read from.
+func (er *EventRepo) RecordMetadataFailure(ctx context.Context, eventId int64, rawResponse string, reason string) error {
+	return er.Queries.CreateMetadataFailure(ctx, CreateMetadataFailureParams{
+		EventID:     eventId,
+		RawResponse: rawResponse,
+		Error:       reason,
+	})
+}
+
+func (er *EventRepo) GetStatusChange(ctx context.Context, id int64) (StatusChange, error) {
+	return er.Queries.GetStatusChange(ctx, id)
+}
+
+// GetUnannouncedStatusChanges returns the status changes worth announcing of
+// the events from fromDate on, that weren't announced yet.
+func (er *EventRepo) GetUnannouncedStatusChanges(ctx context.Context, fromDate time.Time) ([]StatusChange, error) {
+	return er.Queries.GetUnannouncedStatusChanges(ctx, fromDate)
+}
+
+// EventStore holds the queries SaveEvent runs. Queries implements it, the
+// other database backends adapt their queries.
+type EventStore interface {
+	CreateEvent(ctx context.Context, arg CreateEventParams) error
+	CreateEventRevision(ctx context.Context, arg CreateEventRevisionParams) error
+	CreatePostponement(ctx context.Context, arg CreatePostponementParams) error
+	CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error
+	GetEvent(ctx context.Context, id int64) (Event, error)
+	GetEventByLink(ctx context.Context, link string) (Event, error)
+	UpdateEvent(ctx context.Context, arg UpdateEventParams) error
+}
+
 func (er *EventRepo) Save(ctx context.Context, event Event) error {
+	return SaveEvent(ctx, er.conn, er.withTx, event)
+}
+
+func (er *EventRepo) withTx(tx *sql.Tx) EventStore {
+	return er.Queries.WithTx(tx)
+}
+
+// SaveEvent creates or updates the event. Every changed field is recorded in
+// the revisions of the event, a changed status also in the status history
+// along with the kind of the transition.
+func SaveEvent(ctx context.Context, conn *sql.DB, withTx func(tx *sql.Tx) EventStore, event Event) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := withTx(tx)
+
+	// A known link updates the event, so the changes are recorded
 	if event.ID == 0 {
-		return er.Queries.CreateEvent(ctx, CreateEventParams{
+		stored, err := queries.GetEventByLink(ctx, event.Link)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err == nil {
+			stored.Name, stored.Place, stored.Status, stored.Date = event.Name, event.Place, event.Status, event.Date
+			event = stored
+		}
+	}
+
+	if event.ID == 0 {
+		err := queries.CreateEvent(ctx, CreateEventParams{
 			Name:         event.Name,
 			Place:        event.Place,
 			Status:       event.Status,
 			Link:         event.Link,
 			Date:         event.Date,
 			ArtistImgUrl: event.ArtistImgUrl,
+			Source:       event.Source,
+			StartsAt:     event.StartsAt,
+			DoorsAt:      event.DoorsAt,
 		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
 	}
 
-	return er.Queries.UpdateEvent(ctx, UpdateEventParams{
+	stored, err := queries.GetEvent(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	err = queries.UpdateEvent(ctx, UpdateEventParams{
 		Name:               event.Name,
 		Place:              event.Place,
 		Status:             event.Status,
@@ -85,6 +195,133 @@ func (er *EventRepo) Save(ctx context.Context, event Event) error {
 		ReportedAtNew:      event.ReportedAtNew,
 		ReportedAtUpcoming: event.ReportedAtUpcoming,
 		PostponedDate:      event.PostponedDate,
+		StartsAt:           event.StartsAt,
+		DoorsAt:            event.DoorsAt,
+		LockedFields:       event.LockedFields,
 		ID:                 event.ID,
 	})
+	if err != nil {
+		return err
+	}
+
+	// A new postponed date means the event moved, keep every previous date
+	if event.PostponedDate.Valid && formatNullTime(stored.PostponedDate) != formatNullTime(event.PostponedDate) {
+		err := queries.CreatePostponement(ctx, CreatePostponementParams{
+			EventID:      event.ID,
+			PreviousDate: event.PostponedDate.Time,
+			NewDate:      event.Date,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, revision := range Revisions(stored, event) {
+		if err := queries.CreateEventRevision(ctx, revision); err != nil {
+			return err
+		}
+	}
+
+	if stored.Status != event.Status {
+		err := queries.CreateStatusChange(ctx, CreateStatusChangeParams{
+			EventID:   event.ID,
+			OldStatus: stored.Status,
+			NewStatus: event.Status,
+			Kind:      status.Transition(stored.Status, event.Status),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// revisionStore holds the queries trackRevisions runs.
+type revisionStore interface {
+	CreateEventRevision(ctx context.Context, arg CreateEventRevisionParams) error
+	GetEvent(ctx context.Context, id int64) (Event, error)
+}
+
+// trackRevisions runs an update of the event outside of Save and records
+// the fields it changed.
+func trackRevisions(ctx context.Context, queries revisionStore, id int64, update func() error) error {
+	stored, err := queries.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+
+	event, err := queries.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, revision := range Revisions(stored, event) {
+		if err := queries.CreateEventRevision(ctx, revision); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revisions lists the changed fields of the event, with their old and new
+// values.
+func Revisions(stored Event, event Event) []CreateEventRevisionParams {
+	fields := []struct {
+		name     string
+		oldValue string
+		newValue string
+	}{
+		{"name", stored.Name, event.Name},
+		{"place", stored.Place, event.Place},
+		{"status", stored.Status, event.Status},
+		{"link", stored.Link, event.Link},
+		{"date", formatTime(stored.Date), formatTime(event.Date)},
+		{"artist", stored.Artist.String, event.Artist.String},
+		{"category", stored.Category.String, event.Category.String},
+		{"artist_url", stored.ArtistUrl.String, event.ArtistUrl.String},
+		{"artist_img_url", stored.ArtistImgUrl.String, event.ArtistImgUrl.String},
+		{"reported_at_new", formatNullTime(stored.ReportedAtNew), formatNullTime(event.ReportedAtNew)},
+		{"reported_at_upcoming", formatNullTime(stored.ReportedAtUpcoming), formatNullTime(event.ReportedAtUpcoming)},
+		{"postponed_date", formatNullTime(stored.PostponedDate), formatNullTime(event.PostponedDate)},
+		{"starts_at", formatNullTime(stored.StartsAt), formatNullTime(event.StartsAt)},
+		{"doors_at", formatNullTime(stored.DoorsAt), formatNullTime(event.DoorsAt)},
+		{"locked_fields", stored.LockedFields, event.LockedFields},
+		{"missing_runs", strconv.FormatInt(stored.MissingRuns, 10), strconv.FormatInt(event.MissingRuns, 10)},
+		{"removed_at", formatNullTime(stored.RemovedAt), formatNullTime(event.RemovedAt)},
+	}
+
+	var revisions []CreateEventRevisionParams
+
+	for _, field := range fields {
+		if field.oldValue == field.newValue {
+			continue
+		}
+
+		revisions = append(revisions, CreateEventRevisionParams{
+			EventID:  event.ID,
+			Field:    field.name,
+			OldValue: field.oldValue,
+			NewValue: field.newValue,
+		})
+	}
+
+	return revisions
+}
+
+func formatTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339)
+}
+
+func formatNullTime(value sql.NullTime) string {
+	if !value.Valid {
+		return ""
+	}
+
+	return formatTime(value.Time)
 }
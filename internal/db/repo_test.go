@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/status"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveStatusChanges(t *testing.T) {
+	ctx := context.Background()
+	eventRepo := NewEventRepoFromConn(prepareConnection(t))
+
+	assert.Nil(t, eventRepo.Save(ctx, Event{Name: "event-1", Status: "Tickets", Link: "link-1", Date: time.Now().AddDate(0, 0, 1)}))
+	event, _ := eventRepo.GetById(ctx, 1)
+
+	for _, newStatus := range []string{"Tickets", "Tickets kaufen", "Ausverkauft"} {
+		event.Status = newStatus
+		assert.Nil(t, eventRepo.Save(ctx, event))
+	}
+
+	changes, err := eventRepo.Queries.GetStatusChanges(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "Tickets", changes[0].OldStatus)
+	assert.Equal(t, "Tickets kaufen", changes[0].NewStatus)
+	assert.Equal(t, "", changes[0].Kind)
+	assert.Equal(t, status.SOLD_OUT, changes[1].Kind)
+
+	unannounced, err := eventRepo.GetUnannouncedStatusChanges(ctx, time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, unannounced, 1)
+	assert.Equal(t, changes[1].ID, unannounced[0].ID)
+
+	// Once every destination got the announcement it isn't pending anymore
+	outboxRepo := NewOutboxRepoFromConn(eventRepo.conn)
+	message := Outbox{EventID: 1, Kind: OUTBOX_KIND_STATUS, Receiver: "receiver", Revision: StatusRevision(unannounced[0]), NextRetryAt: time.Now()}
+	assert.Nil(t, outboxRepo.Enqueue(ctx, message))
+	due, _ := outboxRepo.GetDue(ctx, time.Now(), 3)
+	assert.Nil(t, outboxRepo.MarkDelivered(ctx, due[0], time.Now()))
+
+	unannounced, _ = eventRepo.GetUnannouncedStatusChanges(ctx, time.Now())
+	assert.Len(t, unannounced, 0)
+}
+
+func TestSaveRevisions(t *testing.T) {
+	ctx := context.Background()
+	eventRepo := NewEventRepoFromConn(prepareConnection(t))
+
+	assert.Nil(t, eventRepo.Save(ctx, Event{Name: "event-1", Status: "Tickets", Link: "link-1", Date: time.Now().AddDate(0, 0, 1)}))
+	event, _ := eventRepo.GetById(ctx, 1)
+
+	// Saving the stored state again changes nothing
+	assert.Nil(t, eventRepo.Save(ctx, event))
+	revisions, err := eventRepo.GetRevisions(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, revisions, 0)
+
+	event.Name = "event-2"
+	event.Category = sql.NullString{String: "concert", Valid: true}
+	assert.Nil(t, eventRepo.Save(ctx, event))
+
+	revisions, _ = eventRepo.GetRevisions(ctx, 1)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, []string{"name", "event-1", "event-2"}, []string{revisions[0].Field, revisions[0].OldValue, revisions[0].NewValue})
+	assert.Equal(t, []string{"category", "", "concert"}, []string{revisions[1].Field, revisions[1].OldValue, revisions[1].NewValue})
+}
+
+func TestSavePostponements(t *testing.T) {
+	ctx := context.Background()
+	eventRepo := NewEventRepoFromConn(prepareConnection(t))
+	dates := []time.Time{
+		time.Now().AddDate(0, 0, 5).Truncate(time.Second),
+		time.Now().AddDate(0, 0, 10).Truncate(time.Second),
+		time.Now().AddDate(0, 0, 20).Truncate(time.Second),
+	}
+
+	assert.Nil(t, eventRepo.Save(ctx, Event{Name: "event-1", Link: "link-1", Date: dates[0]}))
+	event, _ := eventRepo.GetById(ctx, 1)
+
+	for i := 1; i < len(dates); i++ {
+		event.PostponedDate = sql.NullTime{Time: event.Date, Valid: true}
+		event.Date = dates[i]
+		assert.Nil(t, eventRepo.Save(ctx, event))
+	}
+
+	// Saving again without a new postponement adds nothing
+	assert.Nil(t, eventRepo.Save(ctx, event))
+
+	postponements, err := eventRepo.GetPostponements(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, postponements, 2)
+	assert.True(t, dates[0].Equal(postponements[0].PreviousDate))
+	assert.True(t, dates[1].Equal(postponements[0].NewDate))
+	assert.True(t, dates[1].Equal(postponements[1].PreviousDate))
+	assert.True(t, dates[2].Equal(postponements[1].NewDate))
+}
@@ -1,11 +1,37 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"os"
+	"path/filepath"
 )
 
-func NewSqliteConn() (*sql.DB, error) {
-	conn, err := sql.Open("sqlite3", "database.sqlite")
+const DEFAULT_PATH = "database.sqlite"
+
+// NewSqliteConn opens the database and applies pending migrations.
+func NewSqliteConn(path string) (*sql.DB, error) {
+	conn, err := OpenSqliteConn(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(context.Background(), conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// OpenSqliteConn opens the database as it is, without migrating it. The
+// directory of the file is created if it does not exist yet.
+func OpenSqliteConn(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open("sqlite3", path)
 
 	if err != nil {
 		return nil, err
@@ -19,8 +45,8 @@ func NewSqliteConn() (*sql.DB, error) {
 	return conn, nil
 }
 
-func NewQueries() (*Queries, error) {
-	conn, err := NewSqliteConn()
+func NewQueries(path string) (*Queries, error) {
+	conn, err := NewSqliteConn(path)
 
 	if err != nil {
 		return nil, err
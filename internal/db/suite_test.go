@@ -0,0 +1,49 @@
+package db_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareConnection(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	assert.Nil(t, err)
+
+	// Every connection would get its own in-memory database
+	conn.SetMaxOpenConns(1)
+	assert.Nil(t, db.Migrate(t.Context(), conn))
+
+	return conn
+}
+
+func TestEventRepoSuite(t *testing.T) {
+	dbtest.TestEventRepository(t, func(t *testing.T) dbtest.Repository {
+		return db.NewEventRepoFromConn(prepareConnection(t))
+	})
+}
+
+func TestOutboxRepoSuite(t *testing.T) {
+	dbtest.TestOutboxRepository(t, func(t *testing.T) (dbtest.Repository, db.OutboxRepository) {
+		conn := prepareConnection(t)
+
+		return db.NewEventRepoFromConn(conn), db.NewOutboxRepoFromConn(conn)
+	})
+}
+
+func TestCrawlRunRepoSuite(t *testing.T) {
+	dbtest.TestCrawlRunRepository(t, func(t *testing.T) (dbtest.Repository, db.CrawlRunRepository) {
+		conn := prepareConnection(t)
+
+		return db.NewEventRepoFromConn(conn), db.NewCrawlRunRepoFromConn(conn)
+	})
+}
+
+func TestLookupCacheRepoSuite(t *testing.T) {
+	dbtest.TestLookupCacheRepository(t, func(t *testing.T) dbtest.LookupCache {
+		return db.NewLookupCacheRepoFromConn(prepareConnection(t))
+	})
+}
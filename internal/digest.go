@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+
+	"github.com/disintegration/imaging"
+	"github.com/samber/lo"
+)
+
+const DIGEST_DAYS_AHEAD = 7
+const DIGEST_MAX_IMAGES = 16
+const DIGEST_TILE_SIZE = 300
+const DIGEST_OTHER_CATEGORY = "other"
+
+// DigestData is passed to the digest templates.
+type DigestData struct {
+	From      time.Time
+	To        time.Time
+	Transport string
+	Weeks     []DigestWeek
+}
+
+type DigestWeek struct {
+	Start      time.Time
+	Categories []DigestCategory
+}
+
+type DigestCategory struct {
+	Name   string
+	Events []db.Event
+}
+
+// SendDigest posts one message with a collage for all events of the next
+// days that were not announced yet. The digest is queued per event and
+// destination, so a failing destination is retried on its own and the events
+// are only marked as reported once every destination received it.
+func (n Notificator) SendDigest(ctx context.Context, destinations []transport.Destination, from time.Time, days int) error {
+	events, err := n.digestEvents(ctx, destinations, from, days)
+
+	if err != nil {
+		return err
+	}
+
+	revision := db.DigestRevision(from, from.AddDate(0, 0, days))
+
+	for _, destination := range destinations {
+		for _, event := range events {
+			err := n.outboxRepo.Enqueue(ctx, db.Outbox{
+				EventID:     event.ID,
+				Kind:        db.OUTBOX_KIND_DIGEST,
+				Transport:   destination.Transport,
+				Receiver:    destination.Receiver,
+				Revision:    revision,
+				NextRetryAt: time.Now(),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return n.ProcessOutbox(ctx)
+}
+
+// PreviewDigest hands the digest to the senders without marking anything as sent.
+func (n Notificator) PreviewDigest(ctx context.Context, destinations []transport.Destination, from time.Time, days int) error {
+	events, err := n.digestEvents(ctx, destinations, from, days)
+
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	var errs []error
+
+	for _, destination := range destinations {
+		params, err := n.buildDigestParams(ctx, destination, from, from.AddDate(0, 0, days), events)
+
+		if err != nil {
+			return err
+		}
+
+		if err := n.senders[destination.Transport].SendWithImage(params); err != nil {
+			errs = append(errs, fmt.Errorf("Could not send digest to [%v]: %w", destination, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n Notificator) digestEvents(ctx context.Context, destinations []transport.Destination, from time.Time, days int) ([]db.Event, error) {
+	for _, destination := range destinations {
+		if _, err := n.sender(destination.Transport); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := n.eventRepo.GetEventsBetween(ctx, from, from.AddDate(0, 0, days))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(events, func(event db.Event, _ int) bool {
+		return !event.ReportedAtUpcoming.Valid && !event.RemovedAt.Valid
+	}), nil
+}
+
+// deliverDigest sends the queued messages of one digest and destination as
+// a single message. Events that were removed or announced in the meantime
+// are dropped from the digest.
+func (n Notificator) deliverDigest(ctx context.Context, messages []db.Outbox) error {
+	from, to, err := db.ParseDigestRevision(messages[0].Revision)
+
+	if err != nil {
+		return err
+	}
+
+	var events []db.Event
+	var pending []db.Outbox
+
+	for _, message := range messages {
+		event, err := n.eventRepo.GetById(ctx, message.EventID)
+
+		if err != nil {
+			return err
+		}
+
+		if event.ReportedAtUpcoming.Valid || event.RemovedAt.Valid {
+			if err := n.outboxRepo.Discard(ctx, message); err != nil {
+				return err
+			}
+			continue
+		}
+
+		events = append(events, event)
+		pending = append(pending, message)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	destination := transport.Destination{Transport: messages[0].Transport, Receiver: messages[0].Receiver}
+
+	params, err := n.buildDigestParams(ctx, destination, from, to, events)
+
+	if err != nil {
+		return err
+	}
+
+	sendErr := n.senders[destination.Transport].SendWithImage(params)
+
+	if sendErr != nil {
+		nextRetryAt := time.Now().Add(retryBackoff(int(messages[0].Attempts) + 1))
+
+		for _, message := range pending {
+			if err := n.outboxRepo.MarkFailed(ctx, message, sendErr, nextRetryAt); err != nil {
+				return err
+			}
+		}
+
+		return fmt.Errorf("Could not send digest to [%v]: %w", destination, sendErr)
+	}
+
+	return n.outboxRepo.MarkAllDelivered(ctx, pending, time.Now())
+}
+
+func (n Notificator) buildDigestParams(ctx context.Context, destination transport.Destination, from time.Time, to time.Time, events []db.Event) (transport.SendImageParams, error) {
+	transportName, _ := transport.SplitProfile(destination.Transport)
+
+	message, err := n.templates.RenderDigest(DigestData{
+		From:      from,
+		To:        to,
+		Transport: transportName,
+		Weeks:     groupDigest(events),
+	})
+
+	if err != nil {
+		return transport.SendImageParams{}, err
+	}
+
+	mimeType, image := buildCollage(events)
+
+	return transport.SendImageParams{
+		Ctx:      ctx,
+		Receiver: destination.Receiver,
+		Message:  message,
+		Image:    image,
+		MimeType: mimeType,
+	}, nil
+}
+
+// groupDigest groups the events by the week they take place in, starting on
+// monday, and by category.
+func groupDigest(events []db.Event) []DigestWeek {
+	events = append([]db.Event{}, events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	var weeks []DigestWeek
+
+	for _, event := range events {
+		start := weekStart(event.Date)
+
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Start.Equal(start) {
+			weeks = append(weeks, DigestWeek{Start: start})
+		}
+
+		week := &weeks[len(weeks)-1]
+
+		category := DIGEST_OTHER_CATEGORY
+		if event.Category.Valid && event.Category.String != "" {
+			category = event.Category.String
+		}
+
+		_, index, found := lo.FindIndexOf(week.Categories, func(c DigestCategory) bool {
+			return c.Name == category
+		})
+
+		if !found {
+			week.Categories = append(week.Categories, DigestCategory{Name: category})
+			index = len(week.Categories) - 1
+		}
+
+		week.Categories[index].Events = append(week.Categories[index].Events, event)
+	}
+
+	for _, week := range weeks {
+		sort.SliceStable(week.Categories, func(i, j int) bool {
+			return week.Categories[i].Name < week.Categories[j].Name
+		})
+	}
+
+	return weeks
+}
+
+func weekStart(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// buildCollage arranges the images of the events in a square grid.
+func buildCollage(events []db.Event) (string, []byte) {
+	var tiles []image.Image
+
+	for _, event := range lo.Slice(events, 0, DIGEST_MAX_IMAGES) {
+		_, content := getEventImage(event)
+
+		img, err := imaging.Decode(bytes.NewReader(content))
+		if err != nil {
+			continue
+		}
+
+		tiles = append(tiles, imaging.Fill(img, DIGEST_TILE_SIZE, DIGEST_TILE_SIZE, imaging.Center, imaging.Lanczos))
+	}
+
+	if len(tiles) == 0 {
+		return getFallbackImge(events[0])
+	}
+
+	columns := int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	rows := int(math.Ceil(float64(len(tiles)) / float64(columns)))
+
+	collage := imaging.New(columns*DIGEST_TILE_SIZE, rows*DIGEST_TILE_SIZE, color.White)
+
+	for i, tile := range tiles {
+		collage = imaging.Paste(collage, tile, image.Pt(i%columns*DIGEST_TILE_SIZE, i/columns*DIGEST_TILE_SIZE))
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+
+	if err := imaging.Encode(buf, collage, imaging.JPEG); err != nil {
+		return getFallbackImge(events[0])
+	}
+
+	return "image/jpeg", buf.Bytes()
+}
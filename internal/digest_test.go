@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendDigest(t *testing.T) {
+	// A monday
+	from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+
+	newEvents := func() []db.Event {
+		return []db.Event{
+			{ID: 1, Name: "Event 1", Date: from.AddDate(0, 0, 8), Category: sql.NullString{String: "concert", Valid: true}},
+			{ID: 2, Name: "Event 2", Date: from.AddDate(0, 0, 1), Category: sql.NullString{String: "party", Valid: true}},
+			{ID: 3, Name: "Event 3", Date: from.AddDate(0, 0, 2), Category: sql.NullString{String: "concert", Valid: true}, PostponedDate: sql.NullTime{Time: from, Valid: true}},
+			{ID: 4, Name: "Event 4", Date: from.AddDate(0, 0, 3)},
+			{ID: 5, Name: "Event 5", Date: from.AddDate(0, 0, 4), ReportedAtUpcoming: sql.NullTime{Time: from, Valid: true}},
+			{ID: 6, Name: "Event 6", Date: from.AddDate(0, 0, 30)},
+		}
+	}
+
+	t.Run("send one message grouped by week and category", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: newEvents()}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.SendDigest(context.Background(), testDestinations, from, 14))
+
+		assert.Len(t, driver.message, 1)
+		assert.Equal(t, "Events 10.03.‘25 - 24.03.‘25\n\n"+
+			"*Week of 10.03.‘25*\n"+
+			"_concert_\n12.03.‘25 Event 3 (postponed)\n"+
+			"_other_\n13.03.‘25 Event 4\n"+
+			"_party_\n11.03.‘25 Event 2\n\n"+
+			"*Week of 17.03.‘25*\n"+
+			"_concert_\n18.03.‘25 Event 1",
+			driver.message[0],
+		)
+
+		reported := []int64{}
+		for _, event := range repo.events {
+			if event.ReportedAtUpcoming.Valid && event.ID != 5 {
+				reported = append(reported, event.ID)
+			}
+		}
+		assert.ElementsMatch(t, []int64{1, 2, 3, 4}, reported)
+	})
+
+	t.Run("don't mark the batch if a destination failed", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		otherDriver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		senders := map[string]transport.Driver{"test": &driver, "other": &otherDriver}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, senders, defaultTemplates(t)}
+
+		err := notificator.SendDigest(context.Background(), []transport.Destination{testDestination, {Transport: "other", Receiver: "receiver"}}, from, 14)
+
+		assert.ErrorContains(t, err, "offline")
+		assert.Len(t, driver.message, 1)
+		assert.False(t, repo.events[0].ReportedAtUpcoming.Valid)
+	})
+
+	t.Run("retry the failed destination only", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		otherDriver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		senders := map[string]transport.Driver{"test": &driver, "other": &otherDriver}
+		notificator := Notificator{&repo, &outbox, senders, defaultTemplates(t)}
+		destinations := []transport.Destination{testDestination, {Transport: "other", Receiver: "receiver"}}
+
+		assert.ErrorContains(t, notificator.SendDigest(context.Background(), destinations, from, 14), "offline")
+
+		// Not due yet
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, otherDriver.message, 0)
+
+		otherDriver.err = nil
+		for i := range outbox.messages {
+			outbox.messages[i].NextRetryAt = time.Now()
+		}
+
+		assert.Nil(t, notificator.SendDigest(context.Background(), destinations, from, 14))
+
+		assert.Len(t, driver.message, 1)
+		assert.Len(t, otherDriver.message, 1)
+		assert.Equal(t, driver.message[0], otherDriver.message[0])
+		assert.True(t, repo.events[0].ReportedAtUpcoming.Valid)
+		assert.True(t, repo.events[3].ReportedAtUpcoming.Valid)
+	})
+
+	t.Run("drop removed events from a queued digest", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		notificator.SendDigest(context.Background(), testDestinations, from, 14)
+
+		driver.err = nil
+		repo.events[0].RemovedAt = sql.NullTime{Time: from, Valid: true}
+		for i := range outbox.messages {
+			outbox.messages[i].NextRetryAt = time.Now()
+		}
+
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 1)
+		assert.NotContains(t, driver.message[0], "Event 1")
+		assert.True(t, repo.events[1].ReportedAtUpcoming.Valid)
+	})
+
+	t.Run("use the templates of the transport for profile destinations", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "digest.test.tmpl"), []byte(`Test digest`), 0644)
+		templates, err := LoadTemplates(dir)
+		assert.Nil(t, err)
+
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: newEvents()}
+		destination := transport.Destination{Transport: "test/promo", Receiver: "receiver"}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, map[string]transport.Driver{"test/promo": &driver}, templates}
+
+		assert.Nil(t, notificator.SendDigest(context.Background(), []transport.Destination{destination}, from, 14))
+		assert.Equal(t, []string{"Test digest"}, driver.message)
+	})
+
+	t.Run("preview without marking", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: newEvents()}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.PreviewDigest(context.Background(), testDestinations, from, 14))
+
+		assert.Len(t, driver.message, 1)
+		assert.False(t, repo.events[0].ReportedAtUpcoming.Valid)
+	})
+
+	t.Run("skip empty digests", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.SendDigest(context.Background(), testDestinations, from, 14))
+		assert.Len(t, driver.message, 0)
+	})
+}
+
+func TestBuildCollage(t *testing.T) {
+	events := []db.Event{
+		{Category: sql.NullString{String: "concert", Valid: true}},
+		{Category: sql.NullString{String: "party", Valid: true}},
+		{},
+	}
+
+	mimeType, content := buildCollage(events)
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.Equal(t, 2*DIGEST_TILE_SIZE, img.Bounds().Dx())
+	assert.Equal(t, 2*DIGEST_TILE_SIZE, img.Bounds().Dy())
+}
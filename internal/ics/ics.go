@@ -0,0 +1,206 @@
+package ics
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/status"
+)
+
+const PRODUCT_ID = "-//zh-notify//Events//DE"
+const UID_DOMAIN = "zh-notify"
+const MAX_LINE_LENGTH = 75
+
+// The venues don't publish when events end
+const DEFAULT_DURATION = 3 * time.Hour
+
+const dateFormat = "20060102"
+const dateTimeFormat = "20060102T150405Z"
+
+// Write renders the events as an RFC 5545 calendar. The UIDs are derived from
+// the event links, so calendar apps update entries instead of duplicating them.
+// sequences holds the sequence of each event by its id, see Sequences.
+func Write(w io.Writer, events []db.Event, sequences map[int64]int, now time.Time) error {
+	cw := calendarWriter{w: bufio.NewWriter(w)}
+
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", PRODUCT_ID)
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+
+	for _, event := range events {
+		cw.event(event, sequences[event.ID], now)
+	}
+
+	cw.line("END", "VCALENDAR")
+
+	if cw.err != nil {
+		return cw.err
+	}
+
+	return cw.w.Flush()
+}
+
+func Uid(event db.Event) string {
+	hash := sha1.Sum([]byte(event.Link))
+
+	return hex.EncodeToString(hash[:]) + "@" + UID_DOMAIN
+}
+
+// Sequences looks up the history of the events and derives their sequence.
+func Sequences(ctx context.Context, repo db.EventRepository, events []db.Event) (map[int64]int, error) {
+	sequences := map[int64]int{}
+
+	for _, event := range events {
+		postponements, err := repo.GetPostponements(ctx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions, err := repo.GetRevisions(ctx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		sequences[event.ID] = Sequence(event, postponements, revisions)
+	}
+
+	return sequences, nil
+}
+
+// Sequence is incremented with every postponement and every change of the
+// status or the removal. It only counts the recorded history, so it doesn't
+// decrease when a status reverts or a removed event reappears. Events
+// postponed before the postponements were recorded count as postponed once.
+func Sequence(event db.Event, postponements []db.EventPostponement, revisions []db.EventRevision) int {
+	sequence := len(postponements)
+	if sequence == 0 && event.PostponedDate.Valid {
+		sequence = 1
+	}
+
+	for _, revision := range revisions {
+		if revision.Field == "status" || revision.Field == "removed_at" {
+			sequence++
+		}
+	}
+
+	return sequence
+}
+
+func IsCancelled(event db.Event) bool {
+	return status.Classify(event.Status) == status.CANCELLED
+}
+
+type calendarWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *calendarWriter) event(event db.Event, sequence int, now time.Time) {
+	eventStatus := "CONFIRMED"
+	// Removed events stay in the feed, so subscribed calendars drop them
+	if IsCancelled(event) || event.RemovedAt.Valid {
+		eventStatus = "CANCELLED"
+	}
+
+	cw.line("BEGIN", "VEVENT")
+	cw.line("UID", Uid(event))
+	cw.line("DTSTAMP", now.UTC().Format(dateTimeFormat))
+
+	// Events without a published start time are all-day entries
+	if event.StartsAt.Valid {
+		cw.line("DTSTART", event.StartsAt.Time.UTC().Format(dateTimeFormat))
+		cw.line("DTEND", event.StartsAt.Time.Add(DEFAULT_DURATION).UTC().Format(dateTimeFormat))
+	} else {
+		cw.line("DTSTART;VALUE=DATE", event.Date.Format(dateFormat))
+		cw.line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateFormat))
+	}
+
+	cw.line("SEQUENCE", strconv.Itoa(sequence))
+	cw.line("STATUS", eventStatus)
+	cw.line("SUMMARY", escape(event.Name))
+
+	if event.Place != "" {
+		cw.line("LOCATION", escape(event.Place))
+	}
+
+	if event.Link != "" {
+		cw.line("URL", event.Link)
+	}
+
+	cw.line("DESCRIPTION", escape(description(event)))
+	cw.line("END", "VEVENT")
+}
+
+func description(event db.Event) string {
+	var lines []string
+
+	if event.Status != "" {
+		lines = append(lines, event.Status)
+	}
+
+	if event.DoorsAt.Valid {
+		lines = append(lines, "Doors: "+event.DoorsAt.Time.Format("15:04"))
+	}
+
+	if event.PostponedDate.Valid {
+		lines = append(lines, "Postponed from "+event.PostponedDate.Time.Format("02.01.2006"))
+	}
+
+	if event.ArtistUrl.Valid {
+		lines = append(lines, "Spotify: "+event.ArtistUrl.String)
+	}
+
+	if event.Link != "" {
+		lines = append(lines, "Info: "+event.Link)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// line writes a content line, folded after 75 octets as the RFC requires.
+func (cw *calendarWriter) line(name, value string) {
+	if cw.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+
+	// Continuation lines start with a space, which counts towards their length
+	limit := MAX_LINE_LENGTH
+
+	for len(content) > limit {
+		cut := limit
+		for !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+
+		if _, cw.err = cw.w.WriteString(content[:cut] + "\r\n "); cw.err != nil {
+			return
+		}
+
+		content = content[cut:]
+		limit = MAX_LINE_LENGTH - 1
+	}
+
+	_, cw.err = cw.w.WriteString(content + "\r\n")
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
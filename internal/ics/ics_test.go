@@ -0,0 +1,139 @@
+package ics
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	event := db.Event{
+		ID:     1,
+		Name:   "Band, live; in concert",
+		Place:  "Zollhaus",
+		Status: "Tickets",
+		Link:   "https://example.com/event-1",
+		Date:   time.Date(2024, 11, 2, 6, 0, 0, 0, time.Local),
+	}
+
+	t.Run("render a confirmed event", func(t *testing.T) {
+		buf := bytes.Buffer{}
+
+		assert.Nil(t, Write(&buf, []db.Event{event}, nil, now))
+
+		content := buf.String()
+		assert.True(t, strings.HasPrefix(content, "BEGIN:VCALENDAR\r\n"))
+		assert.True(t, strings.HasSuffix(content, "END:VCALENDAR\r\n"))
+		assert.Contains(t, content, "UID:"+Uid(event)+"\r\n")
+		assert.Contains(t, content, "DTSTAMP:20241001T120000Z\r\n")
+		assert.Contains(t, content, "DTSTART;VALUE=DATE:20241102\r\n")
+		assert.Contains(t, content, "DTEND;VALUE=DATE:20241103\r\n")
+		assert.Contains(t, content, "SEQUENCE:0\r\n")
+		assert.Contains(t, content, "STATUS:CONFIRMED\r\n")
+		assert.Contains(t, content, `SUMMARY:Band\, live\; in concert`+"\r\n")
+		assert.Contains(t, content, "LOCATION:Zollhaus\r\n")
+	})
+
+	t.Run("postponed events are updates", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		postponed := event
+		postponed.PostponedDate = sql.NullTime{Time: event.Date.AddDate(0, -1, 0), Valid: true}
+
+		assert.Nil(t, Write(&buf, []db.Event{postponed}, map[int64]int{event.ID: 2}, now))
+
+		assert.Contains(t, buf.String(), "UID:"+Uid(event)+"\r\n")
+		assert.Contains(t, buf.String(), "SEQUENCE:2\r\n")
+	})
+
+	t.Run("cancelled events", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		cancelled := event
+		cancelled.Status = "Abgesagt"
+
+		assert.Nil(t, Write(&buf, []db.Event{cancelled}, map[int64]int{event.ID: 1}, now))
+
+		assert.Contains(t, buf.String(), "STATUS:CANCELLED\r\n")
+		assert.Contains(t, buf.String(), "SEQUENCE:1\r\n")
+	})
+
+	t.Run("removed events are cancelled", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		removed := event
+		removed.RemovedAt = sql.NullTime{Time: now, Valid: true}
+
+		assert.Nil(t, Write(&buf, []db.Event{removed}, map[int64]int{event.ID: 1}, now))
+
+		assert.Contains(t, buf.String(), "STATUS:CANCELLED\r\n")
+		assert.Contains(t, buf.String(), "SEQUENCE:1\r\n")
+	})
+
+	t.Run("timed events", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		timed := event
+		timed.StartsAt = sql.NullTime{Time: time.Date(2024, 11, 2, 20, 0, 0, 0, time.UTC), Valid: true}
+		timed.DoorsAt = sql.NullTime{Time: time.Date(2024, 11, 2, 19, 0, 0, 0, time.UTC), Valid: true}
+
+		assert.Nil(t, Write(&buf, []db.Event{timed}, nil, now))
+
+		assert.Contains(t, buf.String(), "DTSTART:20241102T200000Z\r\n")
+		assert.Contains(t, buf.String(), "DTEND:20241102T230000Z\r\n")
+		assert.Contains(t, buf.String(), "Doors: 19:00")
+	})
+
+	t.Run("fold long lines", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		long := event
+		long.Name = strings.Repeat("ä", 100)
+
+		assert.Nil(t, Write(&buf, []db.Event{long}, nil, now))
+
+		for _, line := range strings.Split(buf.String(), "\r\n") {
+			assert.LessOrEqual(t, len(line), MAX_LINE_LENGTH)
+		}
+		assert.Contains(t, strings.ReplaceAll(buf.String(), "\r\n ", ""), "SUMMARY:"+long.Name)
+	})
+}
+
+func TestSequence(t *testing.T) {
+	postponedDate := sql.NullTime{Time: time.Now(), Valid: true}
+	postponements := []db.EventPostponement{{ID: 1}, {ID: 2}}
+	cancelled := []db.EventRevision{{Field: "status", OldValue: "Tickets", NewValue: "Abgesagt"}}
+	reverted := append(cancelled, db.EventRevision{Field: "status", OldValue: "Abgesagt", NewValue: "Tickets"})
+	removed := []db.EventRevision{{Field: "missing_runs", OldValue: "0", NewValue: "1"}, {Field: "removed_at", OldValue: "", NewValue: "2024-10-01T12:00:00Z"}}
+	reappeared := append(removed, db.EventRevision{Field: "removed_at", OldValue: "2024-10-01T12:00:00Z", NewValue: ""})
+
+	tests := []struct {
+		name          string
+		event         db.Event
+		postponements []db.EventPostponement
+		revisions     []db.EventRevision
+		expected      int
+	}{
+		{"unchanged", db.Event{Status: "Tickets"}, nil, nil, 0},
+		{"postponed before the postponements were recorded", db.Event{PostponedDate: postponedDate}, nil, nil, 1},
+		{"postponed twice", db.Event{PostponedDate: postponedDate}, postponements, nil, 2},
+		{"cancelled", db.Event{Status: "Abgesagt"}, nil, cancelled, 1},
+		{"postponed twice and cancelled", db.Event{Status: "Abgesagt", PostponedDate: postponedDate}, postponements, cancelled, 3},
+		{"cancellation reverted", db.Event{Status: "Tickets"}, nil, reverted, 2},
+		{"removed from the listing", db.Event{Status: "Tickets"}, nil, removed, 1},
+		{"reappeared in the listing", db.Event{Status: "Tickets"}, nil, reappeared, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Sequence(test.event, test.postponements, test.revisions))
+		})
+	}
+}
+
+func TestUid(t *testing.T) {
+	assert.Equal(t, Uid(db.Event{ID: 1, Link: "link"}), Uid(db.Event{ID: 2, Link: "link"}))
+	assert.NotEqual(t, Uid(db.Event{Link: "link-1"}), Uid(db.Event{Link: "link-2"}))
+}
@@ -3,123 +3,396 @@ package internal
 import (
 	"bytes"
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
 	"mime"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/apfelfrisch/zh-notify/assets"
 	"github.com/apfelfrisch/zh-notify/internal/db"
 	"github.com/apfelfrisch/zh-notify/internal/transport"
-	"github.com/apfelfrisch/zh-notify/internal/transport/whatsapp"
 
 	"github.com/disintegration/imaging"
+	"github.com/samber/lo"
 )
 
 const DATE_FORMAT = "02.01.‘06"
 const NOTIFY_DAYS_AHEAD = 15
 const MAX_IMAGE_SIZE = 500
+const MAX_SEND_ATTEMPTS = 10
+const RETRY_BASE_DELAY = time.Minute
+const RETRY_MAX_DELAY = 6 * time.Hour
+const IMAGE_TIMEOUT = 10 * time.Second
 
-var sb strings.Builder
+// A hanging image host must not block a notification or a request of the server
+var imageClient = &http.Client{Timeout: IMAGE_TIMEOUT}
 
-func NewNotificator(ctx context.Context, senderJid string) (*Notificator, error) {
-	conn, err := db.NewSqliteConn()
+func NewNotificator(eventRepo db.EventRepository, outboxRepo db.OutboxRepository, senders map[string]transport.Driver, templates *Templates) *Notificator {
+	return &Notificator{eventRepo, outboxRepo, senders, templates}
+}
+
+type Notificator struct {
+	eventRepo  db.EventRepository
+	outboxRepo db.OutboxRepository
+	senders    map[string]transport.Driver
+	templates  *Templates
+}
+
+// Close disconnects the senders that hold a connection.
+func (n Notificator) Close() {
+	for _, sender := range n.senders {
+		if closer, ok := sender.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
+
+func (n Notificator) SendMonthlyEvents(ctx context.Context, destinations []transport.Destination) error {
+	return n.send(ctx, db.OUTBOX_KIND_UPCOMING, destinations)
+}
+
+func (n Notificator) SendFreshEvents(ctx context.Context, destinations []transport.Destination) error {
+	return n.send(ctx, db.OUTBOX_KIND_FRESH, destinations)
+}
+
+// PreviewMonthlyEvents renders the upcoming events like SendMonthlyEvents,
+// but hands them to the sender directly and doesn't mark anything as sent.
+func (n Notificator) PreviewMonthlyEvents(ctx context.Context, destinations []transport.Destination) error {
+	return n.preview(ctx, db.OUTBOX_KIND_UPCOMING, destinations)
+}
+
+// PreviewFreshEvents renders the fresh events like SendFreshEvents, but
+// hands them to the sender directly and doesn't mark anything as sent.
+func (n Notificator) PreviewFreshEvents(ctx context.Context, destinations []transport.Destination) error {
+	return n.preview(ctx, db.OUTBOX_KIND_FRESH, destinations)
+}
+
+// send queues one message per event and destination. Every message is
+// delivered and retried on its own, so a failing destination doesn't hold
+// back or duplicate the others.
+func (n Notificator) send(ctx context.Context, kind string, destinations []transport.Destination) error {
+	for _, destination := range destinations {
+		if _, err := n.sender(destination.Transport); err != nil {
+			return err
+		}
+	}
+
+	events, err := n.pendingEvents(ctx, kind)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	sender, err := whatsapp.Connect(ctx, conn, senderJid)
+	for _, destination := range destinations {
+		if err := n.enqueue(ctx, events, kind, destination); err != nil {
+			return err
+		}
+	}
+
+	return n.ProcessOutbox(ctx)
+}
+
+func (n Notificator) preview(ctx context.Context, kind string, destinations []transport.Destination) error {
+	events, err := n.pendingEvents(ctx, kind)
 
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	for _, destination := range destinations {
+		sender, err := n.sender(destination.Transport)
+
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			params, err := n.buildImageParams(ctx, MessageData{Event: event, Kind: kind}, destination)
+			if err != nil {
+				return err
+			}
+
+			if err := sender.SendWithImage(params); err != nil {
+				return err
+			}
+		}
 	}
 
-	return &Notificator{db.NewEventRepoFromConn(conn), sender}, nil
+	return nil
 }
 
-type Notificator struct {
-	eventRepo db.EventRepository
-	sender    transport.Driver
+// SendStatusChanges announces events that were sold out, cancelled or are
+// running low on tickets. Each transition is sent once per destination.
+func (n Notificator) SendStatusChanges(ctx context.Context, destinations []transport.Destination) error {
+	for _, destination := range destinations {
+		if _, err := n.sender(destination.Transport); err != nil {
+			return err
+		}
+	}
+
+	changes, err := n.eventRepo.GetUnannouncedStatusChanges(ctx, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	for _, destination := range destinations {
+		for _, change := range changes {
+			err := n.outboxRepo.Enqueue(ctx, db.Outbox{
+				EventID:     change.EventID,
+				Kind:        db.OUTBOX_KIND_STATUS,
+				Transport:   destination.Transport,
+				Receiver:    destination.Receiver,
+				Revision:    db.StatusRevision(change),
+				NextRetryAt: time.Now(),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return n.ProcessOutbox(ctx)
+}
+
+// PreviewStatusChanges renders the pending status changes like
+// SendStatusChanges, but doesn't mark anything as sent.
+func (n Notificator) PreviewStatusChanges(ctx context.Context, destinations []transport.Destination) error {
+	changes, err := n.eventRepo.GetUnannouncedStatusChanges(ctx, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	for _, destination := range destinations {
+		sender, err := n.sender(destination.Transport)
+
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			event, err := n.eventRepo.GetById(ctx, change.EventID)
+			if err != nil {
+				return err
+			}
+
+			params, err := n.buildImageParams(ctx, statusMessage(event, change), destination)
+			if err != nil {
+				return err
+			}
+
+			if err := sender.SendWithImage(params); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-func (n Notificator) SendMonthlyEvents(ctx context.Context, receiver string) {
-	events, _ := n.eventRepo.GetUpcomingEvents(ctx, time.Now(), NOTIFY_DAYS_AHEAD)
+func (n Notificator) pendingEvents(ctx context.Context, kind string) ([]db.Event, error) {
+	if kind == db.OUTBOX_KIND_UPCOMING {
+		return n.eventRepo.GetUpcomingEvents(ctx, time.Now(), NOTIFY_DAYS_AHEAD)
+	}
+
+	return n.eventRepo.GetFreshEvents(ctx)
+}
 
+func (n Notificator) enqueue(ctx context.Context, events []db.Event, kind string, destination transport.Destination) error {
 	for _, event := range events {
-		mimeType, image := getEventImage(event)
-
-		err := n.sender.SendWithImage(transport.SendImageParams{
-			Ctx:      ctx,
-			Receiver: receiver,
-			Message:  buildMessage(event, true),
-			Image:    image,
-			MimeType: mimeType,
+		err := n.outboxRepo.Enqueue(ctx, db.Outbox{
+			EventID:     event.ID,
+			Kind:        kind,
+			Transport:   destination.Transport,
+			Receiver:    destination.Receiver,
+			Revision:    revision(event),
+			NextRetryAt: time.Now(),
 		})
 
 		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProcessOutbox sends every message that is due on one of the connected
+// transports. Failed messages are retried with an exponential backoff until
+// MAX_SEND_ATTEMPTS is reached.
+func (n Notificator) ProcessOutbox(ctx context.Context) error {
+	messages, err := n.outboxRepo.GetDue(ctx, time.Now(), MAX_SEND_ATTEMPTS)
+
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var digestKeys []string
+	digests := map[string][]db.Outbox{}
+
+	for _, message := range messages {
+		if _, ok := n.senders[message.Transport]; !ok {
 			continue
 		}
 
-		event.ReportedAtUpcoming = sql.NullTime{Time: time.Now(), Valid: true}
+		// The messages of a digest are sent together, one per destination
+		if message.Kind == db.OUTBOX_KIND_DIGEST {
+			key := fmt.Sprintf("%v|%v|%v", message.Transport, message.Receiver, message.Revision)
+			if _, ok := digests[key]; !ok {
+				digestKeys = append(digestKeys, key)
+			}
+			digests[key] = append(digests[key], message)
+			continue
+		}
+
+		if err := n.deliver(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-		n.eventRepo.Save(ctx, event)
+	for _, key := range digestKeys {
+		if err := n.deliverDigest(ctx, digests[key]); err != nil {
+			errs = append(errs, err)
+		}
 	}
+
+	return errors.Join(errs...)
 }
 
-func (n Notificator) SendFreshEvents(ctx context.Context, receiver string) {
-	events, _ := n.eventRepo.GetFreshEvents(ctx)
+func (n Notificator) deliver(ctx context.Context, message db.Outbox) error {
+	event, err := n.eventRepo.GetById(ctx, message.EventID)
 
-	for _, event := range events {
-		mimeType, image := getEventImage(event)
-
-		n.sender.SendWithImage(transport.SendImageParams{
-			Ctx:      ctx,
-			Receiver: receiver,
-			Message:  buildMessage(event, true),
-			Image:    image,
-			MimeType: mimeType,
-		})
+	if err != nil {
+		return err
+	}
 
-		event.ReportedAtNew = sql.NullTime{Time: time.Now(), Valid: true}
+	data := MessageData{Event: event, Kind: message.Kind}
 
-		n.eventRepo.Save(ctx, event)
+	if message.Kind == db.OUTBOX_KIND_STATUS {
+		// Announce the queued change, even if the status changed again since
+		id, err := db.ParseStatusRevision(message.Revision)
+		if err != nil {
+			return err
+		}
+
+		change, err := n.eventRepo.GetStatusChange(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		data = statusMessage(event, change)
+	} else if message.Revision != revision(event) || event.RemovedAt.Valid {
+		// The event changed or was removed since the message was queued
+		return n.outboxRepo.Discard(ctx, message)
 	}
+
+	params, err := n.buildImageParams(ctx, data, transport.Destination{Transport: message.Transport, Receiver: message.Receiver})
+
+	if err != nil {
+		return err
+	}
+
+	sendErr := n.senders[message.Transport].SendWithImage(params)
+
+	if sendErr != nil {
+		nextRetryAt := time.Now().Add(retryBackoff(int(message.Attempts) + 1))
+
+		if err := n.outboxRepo.MarkFailed(ctx, message, sendErr, nextRetryAt); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("Could not send [%v] to [%v]: %w", event.Name, message.Receiver, sendErr)
+	}
+
+	return n.outboxRepo.MarkDelivered(ctx, message, time.Now())
 }
 
-func buildMessage(event db.Event, withStatus bool) string {
-	sb.Reset()
-	sb.WriteString(event.Name)
-	sb.WriteString("\n\n")
+func (n Notificator) sender(transportName string) (transport.Driver, error) {
+	sender, ok := n.senders[transportName]
 
-	if !event.PostponedDate.Valid {
-		sb.WriteString("*")
-		sb.WriteString(event.Date.Format(DATE_FORMAT))
-		sb.WriteString("*")
-	} else {
-		sb.WriteString("~")
-		sb.WriteString(event.PostponedDate.Time.Format(DATE_FORMAT))
-		sb.WriteString("~ : *")
-		sb.WriteString(event.Date.Format(DATE_FORMAT))
-		sb.WriteString("*")
+	if !ok {
+		return nil, fmt.Errorf("Transport [%v] is not connected", transportName)
 	}
 
-	if withStatus {
-		sb.WriteString(" | ")
-		sb.WriteString(event.Status)
+	return sender, nil
+}
+
+// revision identifies the state of the event a message announces, so a
+// postponed event is announced again.
+func revision(event db.Event) string {
+	return event.Date.Format(time.RFC3339)
+}
+
+// previousDates lists the dates the event was postponed from. Events that
+// were postponed before the postponements were recorded only know the last one.
+func (n Notificator) previousDates(ctx context.Context, event db.Event) ([]time.Time, error) {
+	postponements, err := n.eventRepo.GetPostponements(ctx, event.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(postponements) == 0 && event.PostponedDate.Valid {
+		return []time.Time{event.PostponedDate.Time}, nil
+	}
+
+	return lo.Map(postponements, func(postponement db.EventPostponement, _ int) time.Time {
+		return postponement.PreviousDate
+	}), nil
+}
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := RETRY_BASE_DELAY << (attempts - 1)
+
+	if backoff <= 0 || backoff > RETRY_MAX_DELAY {
+		return RETRY_MAX_DELAY
+	}
+
+	return backoff
+}
+
+func statusMessage(event db.Event, change db.StatusChange) MessageData {
+	return MessageData{
+		Event:      event,
+		Kind:       db.OUTBOX_KIND_STATUS,
+		StatusKind: change.Kind,
+		OldStatus:  change.OldStatus,
+		NewStatus:  change.NewStatus,
+	}
+}
+
+// buildImageParams renders the message for the destination, the event and
+// the kind are taken from data.
+func (n Notificator) buildImageParams(ctx context.Context, data MessageData, destination transport.Destination) (transport.SendImageParams, error) {
+	previousDates, err := n.previousDates(ctx, data.Event)
+
+	if err != nil {
+		return transport.SendImageParams{}, err
 	}
 
-	sb.WriteString("\nLocation: ")
-	sb.WriteString(event.Place)
+	// The templates are picked by the transport, not by its sender profile
+	data.Transport, _ = transport.SplitProfile(destination.Transport)
+	data.PreviousDates = previousDates
+
+	message, err := n.templates.Render(data)
 
-	if event.ArtistUrl.Valid {
-		sb.WriteString("\nSpotify: ")
-		sb.WriteString(event.ArtistUrl.String)
+	if err != nil {
+		return transport.SendImageParams{}, err
 	}
-	sb.WriteString("\nInfo: ")
-	sb.WriteString(event.Link)
-	return sb.String()
+
+	mimeType, image := getEventImage(data.Event)
+
+	return transport.SendImageParams{
+		Ctx:      ctx,
+		Receiver: destination.Receiver,
+		Message:  message,
+		Image:    image,
+		MimeType: mimeType,
+	}, nil
 }
 
 func getEventImage(event db.Event) (string, []byte) {
@@ -127,29 +400,43 @@ func getEventImage(event db.Event) (string, []byte) {
 		return getFallbackImge(event)
 	}
 
-	resp, err := http.Get(event.ArtistImgUrl.String)
+	mimeType, image, err := fetchImage(event.ArtistImgUrl.String)
 	if err != nil {
 		return getFallbackImge(event)
 	}
+
+	return mimeType, image
+}
+
+// fetchImage downloads the image and scales it down to MAX_IMAGE_SIZE.
+func fetchImage(url string) (string, []byte, error) {
+	resp, err := imageClient.Get(url)
+	if err != nil {
+		return "", nil, err
+	}
 	defer resp.Body.Close()
 
-	format, err := imaging.FormatFromFilename(event.ArtistImgUrl.String)
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("Could not fetch image [%v]: %v", url, resp.Status)
+	}
+
+	format, err := imaging.FormatFromFilename(url)
 	if err != nil {
 		format, _ = imaging.FormatFromExtension(".jpeg")
 	}
 
 	img, err := imaging.Decode(resp.Body)
 	if err != nil {
-		return getFallbackImge(event)
+		return "", nil, err
 	}
 
 	buf := bytes.NewBuffer([]byte{})
 
 	if err := imaging.Encode(buf, imaging.Fit(img, MAX_IMAGE_SIZE, MAX_IMAGE_SIZE, imaging.Lanczos), format); err != nil {
-		return getFallbackImge(event)
+		return "", nil, err
 	}
 
-	return mime.TypeByExtension("." + format.String()), buf.Bytes()
+	return mime.TypeByExtension("." + format.String()), buf.Bytes(), nil
 }
 
 func getFallbackImge(event db.Event) (string, []byte) {
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/status"
 	"github.com/apfelfrisch/zh-notify/internal/transport"
 
 	"github.com/samber/lo"
@@ -58,9 +62,9 @@ func TestSendUpcomingEvents(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			driver := InMemoryEventDriver{}
 			repo := InMemoryEventRepo{events: test.events}
-			notificator := Notificator{&repo, &driver}
+			notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-			notificator.SendMonthlyEvents(context.Background(), "receiver")
+			notificator.SendMonthlyEvents(context.Background(), testDestinations)
 
 			sendEvents := lo.Filter(repo.events, func(event db.Event, index int) bool { return event.ReportedAtUpcoming.Valid })
 
@@ -79,9 +83,9 @@ func TestSendUpcomingEvents(t *testing.T) {
 				Name:               "Event 1",
 			},
 		}}
-		notificator := Notificator{&repo, &driver}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-		notificator.SendMonthlyEvents(context.Background(), "receiver")
+		notificator.SendMonthlyEvents(context.Background(), testDestinations)
 
 		assert.Len(t, driver.message, 0)
 	})
@@ -98,9 +102,9 @@ func TestSendUpcomingEvents(t *testing.T) {
 			ArtistUrl: sql.NullString{String: "artist-url", Valid: true},
 		}
 		repo := InMemoryEventRepo{events: []db.Event{event}}
-		notificator := Notificator{&repo, &driver}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-		notificator.SendMonthlyEvents(context.Background(), "receiver")
+		notificator.SendMonthlyEvents(context.Background(), testDestinations)
 
 		assert.Len(t, driver.message, 1)
 
@@ -140,9 +144,9 @@ func TestSendFreshEvents(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			driver := InMemoryEventDriver{}
 			repo := InMemoryEventRepo{events: test.events}
-			notificator := Notificator{&repo, &driver}
+			notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-			notificator.SendFreshEvents(context.Background(), "receiver")
+			notificator.SendFreshEvents(context.Background(), testDestinations)
 
 			assert.Len(t, driver.message, test.sendMessageCount)
 			assert.Len(
@@ -162,9 +166,9 @@ func TestSendFreshEvents(t *testing.T) {
 				Name: "Event 1",
 			},
 		}}
-		notificator := Notificator{&repo, &driver}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-		notificator.SendFreshEvents(context.Background(), "receiver")
+		notificator.SendFreshEvents(context.Background(), testDestinations)
 
 		assert.Len(t, driver.message, 0)
 	})
@@ -181,9 +185,9 @@ func TestSendFreshEvents(t *testing.T) {
 			ArtistUrl: sql.NullString{String: "artist-url", Valid: true},
 		}
 		repo := InMemoryEventRepo{events: []db.Event{event}}
-		notificator := Notificator{&repo, &driver}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, testSenders(&driver), defaultTemplates(t)}
 
-		notificator.SendFreshEvents(context.Background(), "receiver")
+		notificator.SendFreshEvents(context.Background(), testDestinations)
 
 		assert.Len(t, driver.message, 1)
 
@@ -195,6 +199,21 @@ func TestSendFreshEvents(t *testing.T) {
 	})
 
 	// Todo...
+	t.Run("use the templates of the transport for profile destinations", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "fresh.test.tmpl"), []byte(`Test: {{ .Name }}`), 0644)
+		templates, err := LoadTemplates(dir)
+		assert.Nil(t, err)
+
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: []db.Event{{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1"}}}
+		destination := transport.Destination{Transport: "test/promo", Receiver: "receiver"}
+		notificator := Notificator{&repo, &InMemoryOutboxRepo{eventRepo: &repo}, map[string]transport.Driver{"test/promo": &driver}, templates}
+
+		assert.Nil(t, notificator.SendFreshEvents(context.Background(), []transport.Destination{destination}))
+		assert.Equal(t, []string{"Test: Event 1"}, driver.message)
+	})
+
 	// t.Run("....", func(t *testing.T) {
 	// 	mimeType, image := getEventImage(db.Event{
 	// 		ArtistImgUrl: sql.NullString{String: "https://www.zollhaus-leer.com/wp-content/uploads/2024/11/Kachel_Schlagzeugmafia.png", Valid: true},
@@ -208,17 +227,393 @@ func TestSendFreshEvents(t *testing.T) {
 	// })
 }
 
+func TestProcessOutbox(t *testing.T) {
+	newEvents := func() []db.Event {
+		return []db.Event{{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1"}}
+	}
+
+	t.Run("failed sends are not reported and retried later", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		err := notificator.SendFreshEvents(context.Background(), testDestinations)
+
+		assert.ErrorContains(t, err, "offline")
+		assert.False(t, repo.events[0].ReportedAtNew.Valid)
+		assert.Len(t, outbox.messages, 1)
+		assert.Equal(t, int64(1), outbox.messages[0].Attempts)
+		assert.Equal(t, "offline", outbox.messages[0].LastError.String)
+		assert.True(t, outbox.messages[0].NextRetryAt.After(time.Now()))
+
+		// Not due yet
+		driver.err = nil
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 0)
+
+		outbox.messages[0].NextRetryAt = time.Now()
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 1)
+		assert.True(t, repo.events[0].ReportedAtNew.Valid)
+		assert.True(t, outbox.messages[0].DeliveredAt.Valid)
+	})
+
+	t.Run("messages are queued once", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		notificator.SendFreshEvents(context.Background(), testDestinations)
+		notificator.SendFreshEvents(context.Background(), testDestinations)
+
+		assert.Len(t, outbox.messages, 1)
+	})
+
+	t.Run("leave messages of other transports pending", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		outbox.Enqueue(context.Background(), db.Outbox{EventID: 1, Kind: db.OUTBOX_KIND_FRESH, Transport: "other", Receiver: "receiver", Revision: revision(repo.events[0])})
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 0)
+		assert.Equal(t, int64(0), outbox.messages[0].Attempts)
+		assert.ErrorContains(
+			t,
+			notificator.SendFreshEvents(context.Background(), []transport.Destination{{Transport: "other", Receiver: "receiver"}}),
+			"not connected",
+		)
+	})
+
+	t.Run("discard messages of postponed events", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		notificator.SendFreshEvents(context.Background(), testDestinations)
+
+		driver.err = nil
+		repo.events[0].Date = repo.events[0].Date.AddDate(0, 1, 0)
+		outbox.messages[0].NextRetryAt = time.Now()
+
+		assert.Nil(t, notificator.SendFreshEvents(context.Background(), testDestinations))
+		assert.Len(t, driver.message, 1)
+		assert.Len(t, outbox.messages, 1)
+		assert.Equal(t, revision(repo.events[0]), outbox.messages[0].Revision)
+	})
+
+	t.Run("discard messages of removed events", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: newEvents()}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		notificator.SendFreshEvents(context.Background(), testDestinations)
+
+		driver.err = nil
+		repo.events[0].RemovedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		outbox.messages[0].NextRetryAt = time.Now()
+
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 0)
+		assert.Len(t, outbox.messages, 0)
+	})
+}
+
+func TestSendToMultipleDestinations(t *testing.T) {
+	t.Run("a failing destination doesn't block or duplicate the others", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		otherDriver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := InMemoryEventRepo{events: []db.Event{{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1"}}}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		senders := map[string]transport.Driver{"test": &driver, "other": &otherDriver}
+		notificator := Notificator{&repo, &outbox, senders, defaultTemplates(t)}
+		destinations := []transport.Destination{
+			{Transport: "test", Receiver: "receiver-1"},
+			{Transport: "test", Receiver: "receiver-2"},
+			{Transport: "other", Receiver: "receiver-3"},
+		}
+
+		assert.ErrorContains(t, notificator.SendFreshEvents(context.Background(), destinations), "offline")
+		assert.Len(t, driver.message, 2)
+		assert.Len(t, outbox.messages, 3)
+		assert.False(t, repo.events[0].ReportedAtNew.Valid)
+
+		// The event is still pending, only the failed destination is sent again
+		otherDriver.err = nil
+		outbox.messages[2].NextRetryAt = time.Now()
+		assert.Nil(t, notificator.SendFreshEvents(context.Background(), destinations))
+		assert.Len(t, driver.message, 2)
+		assert.Len(t, otherDriver.message, 1)
+		assert.Len(t, outbox.messages, 3)
+		assert.True(t, repo.events[0].ReportedAtNew.Valid)
+	})
+
+	t.Run("reject destinations without a connected transport", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: []db.Event{{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1"}}}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		err := notificator.SendFreshEvents(context.Background(), []transport.Destination{
+			testDestination,
+			{Transport: "other", Receiver: "receiver"},
+		})
+
+		assert.ErrorContains(t, err, "not connected")
+		assert.Len(t, outbox.messages, 0)
+	})
+}
+
+func TestSendStatusChanges(t *testing.T) {
+	newRepo := func() InMemoryEventRepo {
+		return InMemoryEventRepo{
+			events: []db.Event{
+				{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", Status: "Ausverkauft"},
+				{ID: 2, Date: time.Now().AddDate(0, 0, 2), Name: "Event 2", Status: "Abgesagt"},
+			},
+			statusChanges: []db.StatusChange{
+				{ID: 1, EventID: 1, OldStatus: "Tickets", NewStatus: "Ausverkauft", Kind: status.SOLD_OUT},
+				{ID: 2, EventID: 2, OldStatus: "Tickets", NewStatus: "Tickets kaufen", Kind: ""},
+				{ID: 3, EventID: 2, OldStatus: "Tickets kaufen", NewStatus: "Abgesagt", Kind: status.CANCELLED},
+			},
+		}
+	}
+
+	t.Run("announce each transition once", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := newRepo()
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.SendStatusChanges(context.Background(), testDestinations))
+		assert.Nil(t, notificator.SendStatusChanges(context.Background(), testDestinations))
+
+		assert.Len(t, driver.message, 2)
+		assert.True(t, strings.HasPrefix(driver.message[0], "Sold out: Event 1"))
+		assert.True(t, strings.HasPrefix(driver.message[1], "Cancelled: Event 2"))
+		assert.True(t, repo.statusChanges[0].AnnouncedAt.Valid)
+		assert.False(t, repo.statusChanges[1].AnnouncedAt.Valid)
+		assert.True(t, repo.statusChanges[2].AnnouncedAt.Valid)
+	})
+
+	t.Run("retry failed announcements", func(t *testing.T) {
+		driver := InMemoryEventDriver{err: errors.New("offline")}
+		repo := newRepo()
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		assert.ErrorContains(t, notificator.SendStatusChanges(context.Background(), testDestinations), "offline")
+		assert.False(t, repo.statusChanges[0].AnnouncedAt.Valid)
+
+		driver.err = nil
+		for i := range outbox.messages {
+			outbox.messages[i].NextRetryAt = time.Now()
+		}
+
+		assert.Nil(t, notificator.ProcessOutbox(context.Background()))
+		assert.Len(t, driver.message, 2)
+		assert.True(t, repo.statusChanges[0].AnnouncedAt.Valid)
+	})
+
+	t.Run("announce every queued change of an event", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{
+			events: []db.Event{{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", Status: "Abgesagt"}},
+			statusChanges: []db.StatusChange{
+				{ID: 1, EventID: 1, OldStatus: "Tickets", NewStatus: "Wenige Tickets", Kind: status.FEW_TICKETS},
+				{ID: 2, EventID: 1, OldStatus: "Wenige Tickets", NewStatus: "Abgesagt", Kind: status.CANCELLED},
+			},
+		}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.SendStatusChanges(context.Background(), testDestinations))
+
+		assert.Len(t, driver.message, 2)
+		assert.True(t, strings.HasPrefix(driver.message[0], "Few tickets left: Event 1"))
+		assert.True(t, strings.HasPrefix(driver.message[1], "Cancelled: Event 1"))
+		assert.True(t, repo.statusChanges[0].AnnouncedAt.Valid)
+		assert.True(t, repo.statusChanges[1].AnnouncedAt.Valid)
+	})
+}
+
+func TestPreviewEvents(t *testing.T) {
+	t.Run("preview without touching the reported state", func(t *testing.T) {
+		driver := InMemoryEventDriver{}
+		repo := InMemoryEventRepo{events: []db.Event{
+			{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", Status: "available"},
+			{ID: 2, Date: time.Now().AddDate(0, 0, 2), Name: "Event 2", Status: "available"},
+		}}
+		outbox := InMemoryOutboxRepo{eventRepo: &repo}
+		notificator := Notificator{&repo, &outbox, testSenders(&driver), defaultTemplates(t)}
+
+		assert.Nil(t, notificator.PreviewFreshEvents(context.Background(), testDestinations))
+		assert.Nil(t, notificator.PreviewMonthlyEvents(context.Background(), testDestinations))
+
+		assert.Len(t, driver.message, 4)
+		assert.NotContains(t, driver.message[0], "available")
+		assert.Contains(t, driver.message[2], "available")
+		assert.Len(t, outbox.messages, 0)
+		assert.Empty(t, lo.Filter(repo.events, func(event db.Event, index int) bool {
+			return event.ReportedAtNew.Valid || event.ReportedAtUpcoming.Valid
+		}))
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, RETRY_BASE_DELAY, retryBackoff(1))
+	assert.Equal(t, 4*RETRY_BASE_DELAY, retryBackoff(3))
+	assert.Equal(t, RETRY_MAX_DELAY, retryBackoff(MAX_SEND_ATTEMPTS))
+	assert.Equal(t, RETRY_MAX_DELAY, retryBackoff(100))
+}
+
+func defaultTemplates(t *testing.T) *Templates {
+	templates, err := LoadTemplates("")
+	assert.Nil(t, err)
+	return templates
+}
+
+var testDestination = transport.Destination{Transport: "test", Receiver: "receiver"}
+var testDestinations = []transport.Destination{testDestination}
+
+func testSenders(driver *InMemoryEventDriver) map[string]transport.Driver {
+	return map[string]transport.Driver{testDestination.Transport: driver}
+}
+
 type InMemoryEventDriver struct {
 	message []string
+	err     error
 }
 
 func (d *InMemoryEventDriver) SendWithImage(arg transport.SendImageParams) error {
+	if d.err != nil {
+		return d.err
+	}
 	d.message = append(d.message, arg.Message)
 	return nil
 }
 
+type InMemoryOutboxRepo struct {
+	eventRepo *InMemoryEventRepo
+	messages  []db.Outbox
+}
+
+func (or *InMemoryOutboxRepo) Enqueue(ctx context.Context, message db.Outbox) error {
+	for _, m := range or.messages {
+		if m.EventID == message.EventID && m.Kind == message.Kind && m.Transport == message.Transport && m.Receiver == message.Receiver && m.Revision == message.Revision {
+			return nil
+		}
+	}
+	message.ID = int64(len(or.messages) + 1)
+	or.messages = append(or.messages, message)
+	return nil
+}
+
+func (or *InMemoryOutboxRepo) GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]db.Outbox, error) {
+	return lo.Filter(or.messages, func(m db.Outbox, index int) bool {
+		return !m.DeliveredAt.Valid && m.Attempts < int64(maxAttempts) && !m.NextRetryAt.After(now)
+	}), nil
+}
+
+func (or *InMemoryOutboxRepo) MarkDelivered(ctx context.Context, message db.Outbox, deliveredAt time.Time) error {
+	or.update(message.ID, func(m *db.Outbox) {
+		m.Attempts++
+		m.DeliveredAt = sql.NullTime{Time: deliveredAt, Valid: true}
+	})
+
+	for _, m := range or.messages {
+		if m.EventID == message.EventID && m.Kind == message.Kind && m.Revision == message.Revision && !m.DeliveredAt.Valid {
+			return nil
+		}
+	}
+
+	for i := range or.eventRepo.events {
+		if or.eventRepo.events[i].ID != message.EventID {
+			continue
+		}
+		switch message.Kind {
+		case db.OUTBOX_KIND_FRESH:
+			or.eventRepo.events[i].ReportedAtNew = sql.NullTime{Time: deliveredAt, Valid: true}
+		case db.OUTBOX_KIND_UPCOMING, db.OUTBOX_KIND_DIGEST:
+			or.eventRepo.events[i].ReportedAtUpcoming = sql.NullTime{Time: deliveredAt, Valid: true}
+		}
+	}
+
+	for i := range or.eventRepo.statusChanges {
+		if message.Kind == db.OUTBOX_KIND_STATUS && db.StatusRevision(or.eventRepo.statusChanges[i]) == message.Revision {
+			or.eventRepo.statusChanges[i].AnnouncedAt = sql.NullTime{Time: deliveredAt, Valid: true}
+		}
+	}
+	return nil
+}
+
+func (or *InMemoryOutboxRepo) MarkAllDelivered(ctx context.Context, messages []db.Outbox, deliveredAt time.Time) error {
+	for _, message := range messages {
+		if err := or.MarkDelivered(ctx, message, deliveredAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (or *InMemoryOutboxRepo) MarkFailed(ctx context.Context, message db.Outbox, sendErr error, nextRetryAt time.Time) error {
+	or.update(message.ID, func(m *db.Outbox) {
+		m.Attempts++
+		m.LastError = sql.NullString{String: sendErr.Error(), Valid: true}
+		m.NextRetryAt = nextRetryAt
+	})
+	return nil
+}
+
+func (or *InMemoryOutboxRepo) Discard(ctx context.Context, message db.Outbox) error {
+	or.messages = lo.Reject(or.messages, func(m db.Outbox, index int) bool { return m.ID == message.ID })
+	return nil
+}
+
+func (or *InMemoryOutboxRepo) update(id int64, fn func(m *db.Outbox)) {
+	for i := range or.messages {
+		if or.messages[i].ID == id {
+			fn(&or.messages[i])
+		}
+	}
+}
+
 type InMemoryEventRepo struct {
-	events []db.Event
+	events        []db.Event
+	statusChanges []db.StatusChange
+	postponements []db.EventPostponement
+	revisions     []db.EventRevision
+}
+
+func (er *InMemoryEventRepo) GetPostponements(ctx context.Context, eventId int64) ([]db.EventPostponement, error) {
+	return lo.Filter(er.postponements, func(postponement db.EventPostponement, index int) bool {
+		return postponement.EventID == eventId
+	}), nil
+}
+
+func (er *InMemoryEventRepo) GetRevisions(ctx context.Context, eventId int64) ([]db.EventRevision, error) {
+	return lo.Filter(er.revisions, func(revision db.EventRevision, index int) bool {
+		return revision.EventID == eventId
+	}), nil
+}
+
+func (er *InMemoryEventRepo) GetStatusChange(ctx context.Context, id int64) (db.StatusChange, error) {
+	change, ok := lo.Find(er.statusChanges, func(change db.StatusChange) bool { return change.ID == id })
+	if !ok {
+		return db.StatusChange{}, sql.ErrNoRows
+	}
+	return change, nil
+}
+
+func (er *InMemoryEventRepo) GetUnannouncedStatusChanges(ctx context.Context, fromDate time.Time) ([]db.StatusChange, error) {
+	return lo.Filter(er.statusChanges, func(change db.StatusChange, index int) bool {
+		return !change.AnnouncedAt.Valid && change.Kind != ""
+	}), nil
 }
 
 func (er *InMemoryEventRepo) GetUpcomingEvents(ctx context.Context, fromDate time.Time, daysAhead int) ([]db.Event, error) {
@@ -240,6 +635,12 @@ func (er *InMemoryEventRepo) GetUpcomingEvents(ctx context.Context, fromDate tim
 	}), nil
 }
 
+func (er *InMemoryEventRepo) GetEventsBetween(ctx context.Context, fromDate time.Time, toDate time.Time) ([]db.Event, error) {
+	return lo.Filter(er.events, func(event db.Event, index int) bool {
+		return !event.Date.Before(fromDate) && !event.Date.After(toDate)
+	}), nil
+}
+
 func (er *InMemoryEventRepo) GetFreshEvents(ctx context.Context) ([]db.Event, error) {
 	return lo.Filter(er.events, func(event db.Event, index int) bool {
 		if event.Date.Before(time.Now()) {
@@ -250,7 +651,12 @@ func (er *InMemoryEventRepo) GetFreshEvents(ctx context.Context) ([]db.Event, er
 }
 
 func (er *InMemoryEventRepo) GetById(ctx context.Context, id int64) (db.Event, error) {
-	return db.Event{}, errors.New("unimplemented")
+	for _, event := range er.events {
+		if event.ID == id {
+			return event, nil
+		}
+	}
+	return db.Event{}, sql.ErrNoRows
 }
 
 func (er *InMemoryEventRepo) GetByLink(ctx context.Context, link string) (db.Event, error) {
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+)
+
+type Job func(ctx context.Context) error
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{cron: cron.New()}
+}
+
+// Scheduler runs jobs on cron schedules, but never two jobs at the same
+// time, because they all share the same database.
+type Scheduler struct {
+	cron *cron.Cron
+	lock sync.Mutex
+	ctx  context.Context
+}
+
+func (s *Scheduler) Add(name string, spec string, job Job) error {
+	_, err := s.cron.AddFunc(spec, func() {
+		s.run(name, job)
+	})
+
+	return err
+}
+
+// Run starts the scheduler and blocks until ctx is done. A running job is
+// allowed to finish, jobs that are still waiting for their turn are skipped.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.ctx = ctx
+	s.cron.Start()
+
+	<-ctx.Done()
+
+	<-s.cron.Stop().Done()
+}
+
+func (s *Scheduler) run(name string, job Job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	log.Printf("Running %v", name)
+
+	// Don't abort a running job halfway, shutdown waits for it instead
+	if err := job(context.WithoutCancel(s.ctx)); err != nil {
+		log.Printf("%v failed: %v", name, err)
+		return
+	}
+
+	log.Printf("%v finished", name)
+}
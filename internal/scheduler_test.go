@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("jobs never run at the same time", func(t *testing.T) {
+		scheduler := NewScheduler()
+		scheduler.ctx = context.Background()
+
+		var running, maxRunning, runs atomic.Int32
+		job := func(ctx context.Context) error {
+			current := running.Add(1)
+			if current > maxRunning.Load() {
+				maxRunning.Store(current)
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			runs.Add(1)
+			return nil
+		}
+
+		var waitGroup sync.WaitGroup
+		for range 5 {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				scheduler.run("job", job)
+			}()
+		}
+		waitGroup.Wait()
+
+		assert.Equal(t, int32(5), runs.Load())
+		assert.Equal(t, int32(1), maxRunning.Load())
+	})
+
+	t.Run("skip jobs after shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		scheduler := NewScheduler()
+		scheduler.ctx = ctx
+
+		ran := false
+		scheduler.run("job", func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+
+		assert.False(t, ran)
+	})
+
+	t.Run("reject invalid schedules", func(t *testing.T) {
+		scheduler := NewScheduler()
+
+		assert.NotNil(t, scheduler.Add("job", "every minute", func(ctx context.Context) error { return nil }))
+		assert.Nil(t, scheduler.Add("job", "*/5 * * * *", func(ctx context.Context) error { return nil }))
+	})
+}
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/ics"
+
+	"github.com/samber/lo"
+)
+
+const API_DATE_FORMAT = "2006-01-02"
+const API_DEFAULT_DAYS_AHEAD = 365
+const IMAGE_CACHE_SIZE = 256
+
+func NewServer(eventRepo db.EventRepository) *Server {
+	server := &Server{eventRepo: eventRepo, mux: http.NewServeMux(), images: map[string]cachedImage{}}
+
+	server.mux.HandleFunc("GET /events", server.listEvents)
+	server.mux.HandleFunc("GET /events.ics", server.calendar)
+	server.mux.HandleFunc("GET /events/{id}", server.getEvent)
+	server.mux.HandleFunc("GET /events/{id}/image", server.eventImage)
+
+	return server
+}
+
+// Server exposes the events as a read only HTTP API.
+type Server struct {
+	eventRepo db.EventRepository
+	mux       *http.ServeMux
+	// The scaled artist images by their url
+	images      map[string]cachedImage
+	imagesMutex sync.Mutex
+}
+
+type cachedImage struct {
+	mimeType string
+	content  []byte
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type eventResponse struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	Place         string     `json:"place"`
+	Status        string     `json:"status"`
+	Link          string     `json:"link"`
+	Date          time.Time  `json:"date"`
+	PostponedDate *time.Time `json:"postponed_date"`
+	Artist        *string    `json:"artist"`
+	Category      *string    `json:"category"`
+	ArtistUrl     *string    `json:"artist_url"`
+	ImageUrl      string     `json:"image_url"`
+	Source        string     `json:"source"`
+}
+
+func newEventResponse(event db.Event) eventResponse {
+	return eventResponse{
+		ID:            event.ID,
+		Name:          event.Name,
+		Place:         event.Place,
+		Status:        event.Status,
+		Link:          event.Link,
+		Date:          event.Date,
+		PostponedDate: nullTime(event.PostponedDate),
+		Artist:        nullString(event.Artist),
+		Category:      nullString(event.Category),
+		ArtistUrl:     nullString(event.ArtistUrl),
+		ImageUrl:      "/events/" + strconv.FormatInt(event.ID, 10) + "/image",
+		Source:        event.Source,
+	}
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := s.eventsInRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events = lo.Filter(events, func(event db.Event, _ int) bool {
+		return !event.RemovedAt.Valid
+	})
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		events = lo.Filter(events, func(event db.Event, _ int) bool {
+			return event.Category.String == category
+		})
+	}
+
+	writeJson(w, lo.Map(events, func(event db.Event, _ int) eventResponse {
+		return newEventResponse(event)
+	}))
+}
+
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := s.findEvent(w, r)
+	if !ok {
+		return
+	}
+
+	writeJson(w, newEventResponse(event))
+}
+
+func (s *Server) eventImage(w http.ResponseWriter, r *http.Request) {
+	event, ok := s.findEvent(w, r)
+	if !ok {
+		return
+	}
+
+	mimeType, image := s.cachedEventImage(event)
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(image)
+}
+
+func (s *Server) calendar(w http.ResponseWriter, r *http.Request) {
+	events, err := s.eventsInRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sequences, err := ics.Sequences(r.Context(), s.eventRepo, events)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	buf := bytes.Buffer{}
+	if err := ics.Write(&buf, events, sequences, time.Now()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// cachedEventImage fetches an artist image once, failed downloads fall back
+// to the category image and are tried again on the next request.
+func (s *Server) cachedEventImage(event db.Event) (string, []byte) {
+	if !event.ArtistImgUrl.Valid {
+		return getFallbackImge(event)
+	}
+
+	url := event.ArtistImgUrl.String
+
+	s.imagesMutex.Lock()
+	cached, ok := s.images[url]
+	s.imagesMutex.Unlock()
+
+	if ok {
+		return cached.mimeType, cached.content
+	}
+
+	mimeType, image, err := fetchImage(url)
+	if err != nil {
+		return getFallbackImge(event)
+	}
+
+	s.imagesMutex.Lock()
+	defer s.imagesMutex.Unlock()
+
+	// Start over instead of growing without bound
+	if len(s.images) >= IMAGE_CACHE_SIZE {
+		clear(s.images)
+	}
+	s.images[url] = cachedImage{mimeType, image}
+
+	return mimeType, image
+}
+
+// eventsInRange reads the optional from/to query parameters, by default all
+// events from today on are returned.
+func (s *Server) eventsInRange(r *http.Request) ([]db.Event, error) {
+	from := time.Now()
+	to := from.AddDate(0, 0, API_DEFAULT_DAYS_AHEAD)
+
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.ParseInLocation(API_DATE_FORMAT, value, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid " + param + " date, expected YYYY-MM-DD")
+		}
+		*target = parsed
+	}
+
+	return s.eventRepo.GetEventsBetween(r.Context(), from, to)
+}
+
+func (s *Server) findEvent(w http.ResponseWriter, r *http.Request) (db.Event, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid event id"))
+		return db.Event{}, false
+	}
+
+	event, err := s.eventRepo.GetById(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && event.RemovedAt.Valid) {
+		writeError(w, http.StatusNotFound, errors.New("event not found"))
+		return db.Event{}, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return db.Event{}, false
+	}
+
+	return event, true
+}
+
+func writeJson(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
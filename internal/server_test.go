@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	repo := InMemoryEventRepo{events: []db.Event{
+		{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", Category: sql.NullString{String: "concert", Valid: true}},
+		{ID: 2, Date: time.Now().AddDate(0, 0, 2), Name: "Event 2", Category: sql.NullString{String: "party", Valid: true}},
+		{ID: 3, Date: time.Now().AddDate(0, 0, -1), Name: "Event 3"},
+		{ID: 4, Date: time.Now().AddDate(0, 2, 0), Name: "Event 4", Link: "link-4"},
+		{ID: 5, Date: time.Now().AddDate(0, 0, 3), Name: "Event 5", RemovedAt: sql.NullTime{Time: time.Now(), Valid: true}},
+	}}
+	server := NewServer(&repo)
+
+	request := func(url string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		return recorder
+	}
+
+	listNames := func(recorder *httptest.ResponseRecorder) []string {
+		var events []eventResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &events))
+
+		names := []string{}
+		for _, event := range events {
+			names = append(names, event.Name)
+		}
+		return names
+	}
+
+	t.Run("list upcoming events", func(t *testing.T) {
+		recorder := request("/events")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{"Event 1", "Event 2", "Event 4"}, listNames(recorder))
+	})
+
+	t.Run("filter by category", func(t *testing.T) {
+		recorder := request("/events?category=party")
+
+		assert.Equal(t, []string{"Event 2"}, listNames(recorder))
+	})
+
+	t.Run("filter by date range", func(t *testing.T) {
+		recorder := request("/events?to=" + time.Now().AddDate(0, 1, 0).Format(API_DATE_FORMAT))
+
+		assert.Equal(t, []string{"Event 1", "Event 2"}, listNames(recorder))
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("/events?from=tomorrow").Code)
+	})
+
+	t.Run("get event by id", func(t *testing.T) {
+		recorder := request("/events/2")
+
+		var event eventResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &event))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "Event 2", event.Name)
+		assert.Equal(t, "party", *event.Category)
+		assert.Nil(t, event.Artist)
+		assert.Equal(t, "/events/2/image", event.ImageUrl)
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, request("/events/99").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/events/abc").Code)
+		assert.Equal(t, http.StatusNotFound, request("/events/5").Code)
+	})
+
+	t.Run("event image falls back to category image", func(t *testing.T) {
+		recorder := request("/events/1/image")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "image/jpeg", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, getFileContent("images/concert.jpeg"), recorder.Body.Bytes())
+	})
+
+	t.Run("event images are fetched once", func(t *testing.T) {
+		hits := 0
+		imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			imaging.Encode(w, imaging.New(10, 10, color.White), imaging.PNG)
+		}))
+		defer imageServer.Close()
+
+		repo := InMemoryEventRepo{events: []db.Event{
+			{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", ArtistImgUrl: sql.NullString{String: imageServer.URL + "/artist.png", Valid: true}},
+		}}
+		server := NewServer(&repo)
+
+		for range 2 {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events/1/image", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+		}
+
+		assert.Equal(t, 1, hits)
+	})
+
+	t.Run("failed image downloads are not cached", func(t *testing.T) {
+		hits := 0
+		imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer imageServer.Close()
+
+		repo := InMemoryEventRepo{events: []db.Event{
+			{ID: 1, Date: time.Now().AddDate(0, 0, 1), Name: "Event 1", ArtistImgUrl: sql.NullString{String: imageServer.URL + "/artist.png", Valid: true}},
+		}}
+		server := NewServer(&repo)
+
+		for range 2 {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events/1/image", nil))
+
+			assert.Equal(t, getFileContent("images/fallback.jpeg"), recorder.Body.Bytes())
+		}
+
+		assert.Equal(t, 2, hits)
+	})
+
+	t.Run("ics feed", func(t *testing.T) {
+		recorder := request("/events.ics")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Header().Get("Content-Type"), "text/calendar")
+		assert.Contains(t, recorder.Body.String(), "SUMMARY:Event 4")
+		assert.NotContains(t, recorder.Body.String(), "SUMMARY:Event 3")
+		assert.Contains(t, recorder.Body.String(), "STATUS:CANCELLED\r\nSUMMARY:Event 5")
+	})
+}
@@ -0,0 +1,43 @@
+package status
+
+import "strings"
+
+const SOLD_OUT = "sold_out"
+const CANCELLED = "cancelled"
+const FEW_TICKETS = "few_tickets"
+
+// The ticket button texts of the venues, the first match wins
+var keywords = []struct {
+	kind  string
+	words []string
+}{
+	{CANCELLED, []string{"abgesagt", "entfällt", "cancelled", "canceled"}},
+	{SOLD_OUT, []string{"ausverkauft", "sold out"}},
+	{FEW_TICKETS, []string{"wenige", "restkarten", "letzte tickets", "few tickets"}},
+}
+
+// Classify maps a status text to SOLD_OUT, CANCELLED or FEW_TICKETS, or an
+// empty string when it is nothing worth announcing.
+func Classify(status string) string {
+	status = strings.ToLower(status)
+
+	for _, keyword := range keywords {
+		for _, word := range keyword.words {
+			if strings.Contains(status, word) {
+				return keyword.kind
+			}
+		}
+	}
+
+	return ""
+}
+
+// Transition returns the kind of the new status, if the change from the old
+// status is worth announcing.
+func Transition(oldStatus string, newStatus string) string {
+	if Classify(oldStatus) == Classify(newStatus) {
+		return ""
+	}
+
+	return Classify(newStatus)
+}
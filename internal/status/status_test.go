@@ -0,0 +1,30 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransition(t *testing.T) {
+	cases := []struct {
+		name      string
+		oldStatus string
+		newStatus string
+		expected  string
+	}{
+		{"sold out", "Tickets", "Ausverkauft", SOLD_OUT},
+		{"cancelled", "Tickets", "Abgesagt!", CANCELLED},
+		{"few tickets", "Tickets", "Nur noch wenige Tickets", FEW_TICKETS},
+		{"few tickets sold out", "Restkarten", "AUSVERKAUFT", SOLD_OUT},
+		{"unchanged kind", "Ausverkauft", "Leider ausverkauft", ""},
+		{"available again", "Ausverkauft", "Tickets", ""},
+		{"text change", "Tickets", "Tickets kaufen", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, Transition(c.oldStatus, c.newStatus))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/assets"
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/ics"
+	"github.com/apfelfrisch/zh-notify/internal/status"
+)
+
+const DEFAULT_TEMPLATE_PREFIX = "default"
+const DEFAULT_TEMPLATE = DEFAULT_TEMPLATE_PREFIX + ".tmpl"
+const CLOCK_FORMAT = "15:04"
+const DIGEST_TEMPLATE_PREFIX = "digest"
+
+var templateFuncs = template.FuncMap{
+	"date": func(date time.Time) string {
+		return date.Format(DATE_FORMAT)
+	},
+	"clock": func(date time.Time) string {
+		return date.Format(CLOCK_FORMAT)
+	},
+	"formatDate": func(layout string, date time.Time) string {
+		return date.Format(layout)
+	},
+	"postponed": func(data any) bool {
+		return asEvent(data).PostponedDate.Valid
+	},
+	"cancelled": func(data any) bool {
+		return ics.IsCancelled(asEvent(data))
+	},
+	"statusKind": status.Classify,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+}
+
+// MessageData is passed to the templates, all event fields are available
+// next to the kind of the notification and the transport it is sent with.
+type MessageData struct {
+	db.Event
+	Kind      string
+	Transport string
+	// PreviousDates lists every date the event was postponed from, oldest first
+	PreviousDates []time.Time
+	// The status change a status message announces, the event itself may
+	// have changed again since
+	StatusKind string
+	OldStatus  string
+	NewStatus  string
+}
+
+// Templates render the message of a notification. The template is looked up
+// as <kind>.<transport>.tmpl, then <kind>.tmpl, default.<transport>.tmpl and
+// finally default.tmpl.
+type Templates struct {
+	templates *template.Template
+}
+
+// LoadTemplates parses the embedded templates and, if dir is given, the
+// *.tmpl files in dir, which replace embedded templates of the same name.
+func LoadTemplates(dir string) (*Templates, error) {
+	templates, err := template.New("").Funcs(templateFuncs).ParseFS(assets.Files, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return &Templates{templates}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No templates found in [%v]", dir)
+	}
+
+	if templates, err = templates.ParseFiles(files...); err != nil {
+		return nil, err
+	}
+
+	return &Templates{templates}, nil
+}
+
+func (t *Templates) Render(data MessageData) (string, error) {
+	return t.execute(
+		[]string{
+			data.Kind + "." + data.Transport + ".tmpl",
+			data.Kind + ".tmpl",
+			DEFAULT_TEMPLATE_PREFIX + "." + data.Transport + ".tmpl",
+			DEFAULT_TEMPLATE,
+		},
+		data,
+	)
+}
+
+// RenderDigest renders digest.<transport>.tmpl or digest.tmpl.
+func (t *Templates) RenderDigest(data DigestData) (string, error) {
+	return t.execute([]string{DIGEST_TEMPLATE_PREFIX + "." + data.Transport + ".tmpl", DIGEST_TEMPLATE_PREFIX + ".tmpl"}, data)
+}
+
+func (t *Templates) execute(names []string, data any) (string, error) {
+	for _, name := range names {
+		tmpl := t.templates.Lookup(name)
+		if tmpl == nil {
+			continue
+		}
+
+		buf := bytes.Buffer{}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("Could not render template [%v]: %w", name, err)
+		}
+
+		return strings.TrimSpace(buf.String()), nil
+	}
+
+	return "", fmt.Errorf("No template found, tried %v", strings.Join(names, ", "))
+}
+
+// asEvent lets the helpers accept a single message as well as the events of
+// a digest.
+func asEvent(data any) db.Event {
+	switch data := data.(type) {
+	case MessageData:
+		return data.Event
+	case db.Event:
+		return data
+	}
+
+	return db.Event{}
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/db"
+	"github.com/apfelfrisch/zh-notify/internal/status"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	event := db.Event{
+		Name:   "Event 1",
+		Place:  "Zollhaus",
+		Status: "Tickets available",
+		Link:   "https://zollhaus.de/event-1",
+		Date:   time.Date(2025, 3, 14, 20, 0, 0, 0, time.Local),
+	}
+
+	t.Run("render the default template", func(t *testing.T) {
+		templates := defaultTemplates(t)
+
+		message, err := templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_FRESH, Transport: "whatsapp"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "Event 1\n\n*14.03.‘25*\nLocation: Zollhaus\nInfo: https://zollhaus.de/event-1", message)
+	})
+
+	t.Run("render status, postponement and spotify link", func(t *testing.T) {
+		templates := defaultTemplates(t)
+		event := event
+		event.PostponedDate = sql.NullTime{Time: time.Date(2025, 2, 1, 20, 0, 0, 0, time.Local), Valid: true}
+		event.ArtistUrl = sql.NullString{String: "https://open.spotify.com/artist/1", Valid: true}
+
+		message, err := templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_UPCOMING, Transport: "whatsapp", PreviousDates: []time.Time{event.PostponedDate.Time}})
+
+		assert.Nil(t, err)
+		assert.Equal(
+			t,
+			"Event 1\n\n~01.02.‘25~ : *14.03.‘25* | Tickets available\nLocation: Zollhaus\nSpotify: https://open.spotify.com/artist/1\nInfo: https://zollhaus.de/event-1",
+			message,
+		)
+	})
+
+	t.Run("render start time and all previous dates", func(t *testing.T) {
+		templates := defaultTemplates(t)
+		event := event
+		event.StartsAt = sql.NullTime{Time: time.Date(2025, 3, 14, 20, 0, 0, 0, time.Local), Valid: true}
+		event.DoorsAt = sql.NullTime{Time: time.Date(2025, 3, 14, 19, 30, 0, 0, time.Local), Valid: true}
+		previousDates := []time.Time{
+			time.Date(2025, 1, 10, 0, 0, 0, 0, time.Local),
+			time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local),
+		}
+
+		message, err := templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_FRESH, Transport: "whatsapp", PreviousDates: previousDates})
+
+		assert.Nil(t, err)
+		assert.Contains(t, message, "\n~10.01.‘25~ ~01.02.‘25~ : *14.03.‘25 20:00* (Doors 19:30)\nLocation: Zollhaus")
+	})
+
+	t.Run("prefer the template of the kind and transport", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "fresh.tmpl"), []byte(`New: {{ .Name }}`), 0644)
+		os.WriteFile(filepath.Join(dir, "fresh.telegram.tmpl"), []byte(`{{ upper .Name }} on {{ formatDate "02.01.2006" .Date }}`), 0644)
+
+		templates, err := LoadTemplates(dir)
+		assert.Nil(t, err)
+
+		message, _ := templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_FRESH, Transport: "telegram"})
+		assert.Equal(t, "EVENT 1 on 14.03.2025", message)
+
+		message, _ = templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_FRESH, Transport: "whatsapp"})
+		assert.Equal(t, "New: Event 1", message)
+
+		message, _ = templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_UPCOMING, Transport: "telegram"})
+		assert.Contains(t, message, "\n14.03.‘25 | Tickets available\n")
+	})
+
+	t.Run("render plain text for telegram", func(t *testing.T) {
+		templates := defaultTemplates(t)
+		previousDates := []time.Time{
+			time.Date(2025, 1, 10, 0, 0, 0, 0, time.Local),
+			time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local),
+		}
+
+		message, err := templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_FRESH, Transport: "telegram", PreviousDates: previousDates})
+		assert.Nil(t, err)
+		assert.Equal(t, "Event 1\n\nPostponed from 10.01.‘25, 01.02.‘25 to 14.03.‘25\nLocation: Zollhaus\nInfo: https://zollhaus.de/event-1", message)
+
+		message, err = templates.Render(MessageData{Event: event, Kind: db.OUTBOX_KIND_STATUS, Transport: "telegram", StatusKind: status.SOLD_OUT})
+		assert.Nil(t, err)
+		assert.Equal(t, "Sold out: Event 1\n\n14.03.‘25\nLocation: Zollhaus\nInfo: https://zollhaus.de/event-1", message)
+
+		message, err = templates.RenderDigest(DigestData{From: event.Date, To: event.Date.AddDate(0, 0, 7), Transport: "telegram", Weeks: groupDigest([]db.Event{event})})
+		assert.Nil(t, err)
+		assert.Equal(t, "Events 14.03.‘25 - 21.03.‘25\n\nWeek of 10.03.‘25\nother:\n14.03.‘25 Event 1", message)
+	})
+
+	t.Run("reject invalid templates", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "fresh.tmpl"), []byte(`{{ .Name `), 0644)
+
+		_, err := LoadTemplates(dir)
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,60 @@
+package dryrun
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+)
+
+// Driver prints the messages and stores the images in a directory instead
+// of sending them anywhere.
+type Driver struct {
+	out   io.Writer
+	dir   string
+	count int
+}
+
+func New(out io.Writer, dir string) (*Driver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Driver{out: out, dir: dir}, nil
+}
+
+func (d *Driver) SendWithImage(arg transport.SendImageParams) error {
+	d.count++
+
+	imagePath := filepath.Join(d.dir, fmt.Sprintf("%03d.%v", d.count, extension(arg.MimeType)))
+
+	if err := os.WriteFile(imagePath, arg.Image, 0o644); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(
+		d.out,
+		"=== #%v to %v ===\n%v\n\nImage: %v (%v, %v bytes)\n\n",
+		d.count,
+		arg.Receiver,
+		arg.Message,
+		imagePath,
+		arg.MimeType,
+		len(arg.Image),
+	)
+
+	return err
+}
+
+func extension(mimeType string) string {
+	_, subtype, found := strings.Cut(mimeType, "/")
+
+	if !found || subtype == "" {
+		return "bin"
+	}
+
+	return subtype
+}
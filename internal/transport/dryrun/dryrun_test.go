@@ -0,0 +1,39 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWithImage(t *testing.T) {
+	out := bytes.Buffer{}
+	dir := filepath.Join(t.TempDir(), "images")
+
+	driver, err := New(&out, dir)
+	assert.Nil(t, err)
+
+	for _, mimeType := range []string{"image/jpeg", "image/png"} {
+		err := driver.SendWithImage(transport.SendImageParams{
+			Ctx:      context.Background(),
+			Receiver: "receiver",
+			Message:  "caption for " + mimeType,
+			Image:    []byte(mimeType),
+			MimeType: mimeType,
+		})
+		assert.Nil(t, err)
+	}
+
+	assert.Contains(t, out.String(), "=== #1 to receiver ===\ncaption for image/jpeg\n")
+	assert.Contains(t, out.String(), "=== #2 to receiver ===\ncaption for image/png\n")
+
+	image, err := os.ReadFile(filepath.Join(dir, "002.png"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("image/png"), image)
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+)
+
+const NAME = "telegram"
+const DEFAULT_BASE_URL = "https://api.telegram.org"
+const MAX_CAPTION_LENGTH = 1024
+
+type Service struct {
+	token   string
+	baseUrl string
+	client  *http.Client
+}
+
+func New(token string) *Service {
+	return NewWithBaseUrl(token, DEFAULT_BASE_URL)
+}
+
+// NewWithBaseUrl talks to another Bot API server, e.g. a self hosted one or
+// a stand-in for tests.
+func NewWithBaseUrl(token string, baseUrl string) *Service {
+	return &Service{
+		token:   token,
+		baseUrl: strings.TrimRight(baseUrl, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type apiResponse struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+func (s *Service) SendWithImage(arg transport.SendImageParams) error {
+	body := bytes.Buffer{}
+	form := multipart.NewWriter(&body)
+
+	form.WriteField("chat_id", arg.Receiver)
+	form.WriteField("caption", truncate(arg.Message, MAX_CAPTION_LENGTH))
+
+	photo, err := form.CreateFormFile("photo", "image."+extension(arg.MimeType))
+	if err != nil {
+		return err
+	}
+	if _, err := photo.Write(arg.Image); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(arg.Ctx, http.MethodPost, s.methodUrl("sendPhoto"), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("Unexpected response from Telegram [%v]: %w", resp.Status, err)
+	}
+
+	if !apiResp.Ok {
+		return fmt.Errorf("Telegram rejected the message [%v]: %v", apiResp.ErrorCode, apiResp.Description)
+	}
+
+	return nil
+}
+
+func (s *Service) methodUrl(method string) string {
+	return s.baseUrl + "/bot" + s.token + "/" + method
+}
+
+// truncate shortens the caption to the Telegram limit, which counts
+// characters, not bytes.
+func truncate(message string, length int) string {
+	runes := []rune(message)
+
+	if len(runes) <= length {
+		return message
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+func extension(mimeType string) string {
+	_, subtype, found := strings.Cut(mimeType, "/")
+
+	if !found || subtype == "" {
+		return "jpeg"
+	}
+
+	return subtype
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apfelfrisch/zh-notify/internal/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWithImage(t *testing.T) {
+	params := transport.SendImageParams{
+		Ctx:      context.Background(),
+		Receiver: "@channel",
+		Message:  "caption",
+		Image:    []byte("image-data"),
+		MimeType: "image/png",
+	}
+
+	t.Run("send a photo with caption", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/bottoken/sendPhoto", r.URL.Path)
+			assert.Nil(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "@channel", r.FormValue("chat_id"))
+			assert.Equal(t, "caption", r.FormValue("caption"))
+
+			file, header, err := r.FormFile("photo")
+			assert.Nil(t, err)
+			content, _ := io.ReadAll(file)
+			assert.Equal(t, "image.png", header.Filename)
+			assert.Equal(t, []byte("image-data"), content)
+
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+		}))
+		defer server.Close()
+
+		assert.Nil(t, NewWithBaseUrl("token", server.URL).SendWithImage(params))
+	})
+
+	t.Run("report api errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+		}))
+		defer server.Close()
+
+		err := NewWithBaseUrl("token", server.URL).SendWithImage(params)
+
+		assert.ErrorContains(t, err, "chat not found")
+	})
+
+	t.Run("report unexpected responses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		assert.NotNil(t, NewWithBaseUrl("token", server.URL).SendWithImage(params))
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ääää…", truncate(strings.Repeat("ä", 10), 5))
+}
@@ -2,6 +2,8 @@ package transport
 
 import (
 	"context"
+	"fmt"
+	"strings"
 )
 
 type SendImageParams struct {
@@ -15,3 +17,48 @@ type SendImageParams struct {
 type Driver interface {
 	SendWithImage(arg SendImageParams) error
 }
+
+// Destination is a receiver on a specific transport, e.g. a WhatsApp
+// channel JID or a Telegram chat id.
+type Destination struct {
+	Transport string
+	Receiver  string
+}
+
+func (d Destination) String() string {
+	return d.Transport + ":" + d.Receiver
+}
+
+// ParseDestinations reads a comma separated list of "transport:receiver"
+// pairs, e.g. "whatsapp:123@newsletter,telegram:@channel". The transport may
+// name a sender profile, e.g. "whatsapp/promo:123@newsletter".
+func ParseDestinations(value string) ([]Destination, error) {
+	var destinations []Destination
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		transportName, receiver, found := strings.Cut(item, ":")
+		transportName = strings.TrimSpace(transportName)
+		receiver = strings.TrimSpace(receiver)
+
+		if !found || transportName == "" || receiver == "" {
+			return nil, fmt.Errorf("Invalid destination [%v], expected transport:receiver", item)
+		}
+
+		destinations = append(destinations, Destination{Transport: transportName, Receiver: receiver})
+	}
+
+	return destinations, nil
+}
+
+// SplitProfile splits a transport like "whatsapp/promo" into the transport
+// and the sender profile, the profile is empty if none is given.
+func SplitProfile(transport string) (string, string) {
+	name, profile, _ := strings.Cut(transport, "/")
+
+	return name, profile
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDestinations(t *testing.T) {
+	t.Run("parse a list of destinations", func(t *testing.T) {
+		destinations, err := ParseDestinations(" whatsapp:123@newsletter, telegram:@channel,,telegram:-100:1 ")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Destination{
+			{Transport: "whatsapp", Receiver: "123@newsletter"},
+			{Transport: "telegram", Receiver: "@channel"},
+			{Transport: "telegram", Receiver: "-100:1"},
+		}, destinations)
+	})
+
+	t.Run("parse destinations with sender profiles", func(t *testing.T) {
+		destinations, err := ParseDestinations("whatsapp/promo:123@newsletter,whatsapp:456@newsletter")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Destination{
+			{Transport: "whatsapp/promo", Receiver: "123@newsletter"},
+			{Transport: "whatsapp", Receiver: "456@newsletter"},
+		}, destinations)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		destinations, err := ParseDestinations("")
+
+		assert.Nil(t, err)
+		assert.Empty(t, destinations)
+	})
+
+	t.Run("reject destinations without transport", func(t *testing.T) {
+		_, err := ParseDestinations("whatsapp:123@newsletter,@channel")
+
+		assert.ErrorContains(t, err, "@channel")
+	})
+}
+
+func TestSplitProfile(t *testing.T) {
+	name, profile := SplitProfile("whatsapp/promo")
+	assert.Equal(t, "whatsapp", name)
+	assert.Equal(t, "promo", profile)
+
+	name, profile = SplitProfile("telegram")
+	assert.Equal(t, "telegram", name)
+	assert.Equal(t, "", profile)
+}
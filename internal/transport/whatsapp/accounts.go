@@ -0,0 +1,214 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+const DEFAULT_PROFILE = "default"
+
+// Account is a linked device, Profile is empty for devices that were linked
+// before profiles existed.
+type Account struct {
+	Profile  string
+	JID      types.JID
+	PushName string
+	Platform string
+}
+
+// ListAccounts returns every linked device of the store.
+func ListAccounts(ctx context.Context, db *sql.DB) ([]Account, error) {
+	container, err := upgradedContainer(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := newProfiles(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := profiles.names(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := container.GetAllDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []Account
+
+	for _, device := range devices {
+		if device.ID == nil {
+			continue
+		}
+
+		accounts = append(accounts, Account{
+			Profile:  names[device.ID.String()],
+			JID:      *device.ID,
+			PushName: device.PushName,
+			Platform: device.Platform,
+		})
+	}
+
+	return accounts, nil
+}
+
+// Unlink logs the device of the profile, or of the JID, out and removes it
+// from the store. If WhatsApp can't be reached the device is only removed
+// locally.
+func Unlink(ctx context.Context, db *sql.DB, ref string) (Account, error) {
+	container, err := upgradedContainer(ctx, db)
+	if err != nil {
+		return Account{}, err
+	}
+
+	profiles, err := newProfiles(ctx, db)
+	if err != nil {
+		return Account{}, err
+	}
+
+	device, err := findDevice(ctx, container, profiles, ref)
+	if err != nil {
+		return Account{}, err
+	}
+
+	names, err := profiles.names(ctx)
+	if err != nil {
+		return Account{}, err
+	}
+
+	account := Account{Profile: names[device.ID.String()], JID: *device.ID, PushName: device.PushName, Platform: device.Platform}
+
+	if err := logout(ctx, device); err != nil {
+		if err := container.DeleteDevice(ctx, device); err != nil {
+			return account, err
+		}
+	}
+
+	return account, profiles.delete(ctx, account.JID)
+}
+
+// logout removes the device from the linked devices of the phone, whatsmeow
+// deletes it from the store afterwards.
+func logout(ctx context.Context, device *store.Device) error {
+	client := whatsmeow.NewClient(device, waLog.Stdout("Client", LOGLEVEL, true))
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+
+	if err := client.Logout(ctx); err != nil {
+		client.Disconnect()
+		return err
+	}
+
+	return nil
+}
+
+func findDevice(ctx context.Context, container *sqlstore.Container, profiles *profiles, ref string) (*store.Device, error) {
+	jid, err := profiles.jid(ctx, ref)
+	if err != nil {
+		jid, err = types.ParseJID(ref)
+		if err != nil || jid.User == "" {
+			return nil, fmt.Errorf("Unknown account [%v]", ref)
+		}
+	}
+
+	device, err := container.GetDevice(ctx, jid)
+	if err != nil {
+		return nil, err
+	}
+
+	if device == nil || device.ID == nil {
+		return nil, fmt.Errorf("Unknown account [%v]", ref)
+	}
+
+	return device, nil
+}
+
+func upgradedContainer(ctx context.Context, db *sql.DB) (*sqlstore.Container, error) {
+	container := sqlstore.NewWithDB(db, DB_DIALECT, waLog.Stdout("Database", LOGLEVEL, true))
+
+	if err := container.Upgrade(ctx); err != nil {
+		return nil, err
+	}
+
+	return container, nil
+}
+
+// profiles maps the profile names to the JIDs of the linked devices, the
+// table lives next to the whatsmeow tables in the device store.
+type profiles struct {
+	db *sql.DB
+}
+
+func newProfiles(ctx context.Context, db *sql.DB) (*profiles, error) {
+	_, err := db.ExecContext(ctx, `create table if not exists whatsapp_profiles
+(
+    name TEXT not null constraint whatsapp_profiles_pk primary key,
+    jid TEXT not null unique
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &profiles{db: db}, nil
+}
+
+func (p *profiles) jid(ctx context.Context, name string) (types.JID, error) {
+	var jid string
+
+	err := p.db.QueryRowContext(ctx, "SELECT jid FROM whatsapp_profiles WHERE name = ?", name).Scan(&jid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.JID{}, fmt.Errorf("Unknown whatsapp profile [%v]", name)
+	}
+	if err != nil {
+		return types.JID{}, err
+	}
+
+	return types.ParseJID(jid)
+}
+
+// names returns the profile names by JID.
+func (p *profiles) names(ctx context.Context) (map[string]string, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT name, jid FROM whatsapp_profiles")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+
+	for rows.Next() {
+		var name, jid string
+		if err := rows.Scan(&name, &jid); err != nil {
+			return nil, err
+		}
+		names[jid] = name
+	}
+
+	return names, rows.Err()
+}
+
+func (p *profiles) save(ctx context.Context, name string, jid types.JID) error {
+	_, err := p.db.ExecContext(ctx, "INSERT INTO whatsapp_profiles (name, jid) VALUES (?, ?)", name, jid.String())
+
+	return err
+}
+
+func (p *profiles) delete(ctx context.Context, jid types.JID) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM whatsapp_profiles WHERE jid = ?", jid.String())
+
+	return err
+}
@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/apfelfrisch/zh-notify/internal/transport"
 	"google.golang.org/protobuf/proto"
@@ -14,55 +14,157 @@ import (
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
+const NAME = "whatsapp"
 const DB_DIALECT = "sqlite3"
 const LOGLEVEL = "ERROR"
 
+// The client name shown in the linked devices of the phone, WhatsApp only
+// accepts common "Browser (OS)" names
+const PAIR_CLIENT_NAME = "Chrome (Linux)"
+
+var ErrPairingTimedOut = errors.New("Pairing timed out")
+
 type Service struct {
 	db     *sql.DB
 	Client *whatsmeow.Client
 }
 
-func Register(ctx context.Context, db *sql.DB, processQRCode func(qrCode string)) error {
+// LinkParams configures Register. Without a phone number the device is
+// linked by scanning the QR codes, with one by entering the pairing code on
+// the phone.
+type LinkParams struct {
+	Profile string
+	// Phone number in international format, e.g. "+49 170 1234567"
+	Phone         string
+	OnQRCode      func(code string)
+	OnPairingCode func(code string)
+}
+
+// Register links a new device and stores it under the profile name. Other
+// linked devices are kept, so several accounts can be used side by side.
+// New codes are handed out until the pairing succeeds or ctx is done.
+func Register(ctx context.Context, db *sql.DB, params LinkParams) (types.JID, error) {
+	// The name becomes part of destinations like "whatsapp/<name>:<receiver>"
+	if params.Profile == "" || strings.ContainsAny(params.Profile, ",:/ ") {
+		return types.JID{}, fmt.Errorf("Invalid profile name [%v]", params.Profile)
+	}
+
 	log := waLog.Stdout("Database", LOGLEVEL, true)
 
 	container := sqlstore.NewWithDB(db, DB_DIALECT, log)
 	if err := container.Upgrade(ctx); err != nil {
-		return err
+		return types.JID{}, err
 	}
 
-	deviceStore, err := container.GetFirstDevice(ctx)
+	profiles, err := newProfiles(ctx, db)
 	if err != nil {
-		return err
+		return types.JID{}, err
 	}
 
-	client := whatsmeow.NewClient(deviceStore, log)
-
-	if client.Store.ID != nil {
-		return errors.New("An account is already linked, use force to reset.")
+	if _, err := profiles.jid(ctx, params.Profile); err == nil {
+		return types.JID{}, fmt.Errorf("Profile [%v] is already linked, unlink it first", params.Profile)
 	}
 
-	// No ID stored, new login
-	qrChan, _ := client.GetQRChannel(ctx)
-	err = client.Connect()
-	if err != nil {
-		return err
+	client := whatsmeow.NewClient(container.NewDevice(), log)
+	defer client.Disconnect()
+
+	paired := make(chan types.JID, 1)
+	client.AddEventHandler(func(evt any) {
+		if success, ok := evt.(*events.PairSuccess); ok {
+			paired <- success.ID
+		}
+	})
+
+	for {
+		qrChan, err := client.GetQRChannel(ctx)
+		if err != nil {
+			return types.JID{}, err
+		}
+
+		if err := client.Connect(); err != nil {
+			return types.JID{}, err
+		}
+
+		// WhatsApp closes the connection once the codes run out, start over
+		// with fresh codes until ctx is done
+		success, err := awaitPairing(ctx, client, qrChan, params)
+		if err != nil {
+			return types.JID{}, err
+		}
+
+		if success {
+			break
+		}
 	}
 
-	for evt := range qrChan {
-		if evt.Event == "code" {
-			processQRCode(evt.Code)
-			time.Sleep(10 * time.Second)
+	select {
+	case jid := <-paired:
+		return jid, profiles.save(ctx, params.Profile, jid)
+	case <-ctx.Done():
+		return types.JID{}, ErrPairingTimedOut
+	}
+}
 
-			return nil
-		} else {
-			return errors.New("Could not pair: " + evt.Event)
+// awaitPairing hands out the codes of one connection, it returns false if
+// the codes ran out before the device was paired.
+func awaitPairing(ctx context.Context, client *whatsmeow.Client, qrChan <-chan whatsmeow.QRChannelItem, params LinkParams) (bool, error) {
+	pairingCodeSent := false
+
+	for item := range qrChan {
+		switch item.Event {
+		case whatsmeow.QRChannelEventCode:
+			if params.Phone == "" {
+				params.OnQRCode(item.Code)
+				continue
+			}
+
+			// One pairing code is valid as long as the connection
+			if pairingCodeSent {
+				continue
+			}
+
+			code, err := client.PairPhone(ctx, params.Phone, true, whatsmeow.PairClientChrome, PAIR_CLIENT_NAME)
+			if err != nil {
+				return false, err
+			}
+
+			params.OnPairingCode(code)
+			pairingCodeSent = true
+		case whatsmeow.QRChannelSuccess.Event:
+			return true, nil
+		case whatsmeow.QRChannelTimeout.Event:
+			if ctx.Err() != nil {
+				return false, ErrPairingTimedOut
+			}
+			return false, nil
+		case whatsmeow.QRChannelEventError:
+			return false, fmt.Errorf("Could not pair: %w", item.Error)
+		default:
+			return false, errors.New("Could not pair: " + item.Event)
 		}
 	}
 
-	return errors.New("Could not link device")
+	// The channel is closed without a final item once ctx is done
+	return false, ErrPairingTimedOut
+}
+
+// ConnectProfile connects the device linked under the profile name.
+func ConnectProfile(ctx context.Context, db *sql.DB, profile string) (*Service, error) {
+	profiles, err := newProfiles(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	jid, err := profiles.jid(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return Connect(ctx, db, jid.String())
 }
 
 func Connect(ctx context.Context, db *sql.DB, sender string) (*Service, error) {
@@ -7,20 +7,26 @@ SELECT * FROM events WHERE link = ? LIMIT 1;
 -- name: GetEventsForPeriod :many
 SELECT * FROM events
     WHERE reported_at_upcoming IS NULL
+    AND removed_at IS NULL
     AND (
         DATE(date) >= DATE(?) AND DATE(date) <= DATE(?)
     )
 ORDER BY date;
 
+-- name: GetEventsBetween :many
+SELECT * FROM events
+    WHERE DATE(date) >= DATE(?) AND DATE(date) <= DATE(?)
+ORDER BY date;
+
 -- name: GetFreshEvents :many
-SELECT * FROM events WHERE reported_at_new IS NULL ORDER BY date;
+SELECT * FROM events WHERE reported_at_new IS NULL AND removed_at IS NULL ORDER BY date;
 
 -- name: GetNakedEvents :many
 SELECT * FROM events WHERE reported_at_upcoming IS NULL AND (
-    artist IS NULL
-    OR category IS NULL
-    OR artist_url IS NULL
-    OR artist_img_url IS NULL
+    (artist IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist,%')
+    OR (category IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,category,%')
+    OR (artist_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_url,%')
+    OR (artist_img_url IS NULL AND ',' || locked_fields || ',' NOT LIKE '%,artist_img_url,%')
 ) ORDER BY date;
 
 -- name: MarkFreshEventsAsReported :exec
@@ -46,11 +52,14 @@ SET
     category = ?,
     artist_url = ?,
     artist_img_url = ?,
-    postponed_date = ?
+    postponed_date = ?,
+    starts_at = ?,
+    doors_at = ?,
+    locked_fields = ?
 WHERE id = ?;
 
 -- name: CreateEvent :exec
-INSERT INTO events (name, place, status, link, date, artist_img_url) VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO events (name, place, status, link, date, artist_img_url, source, starts_at, doors_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(link) DO UPDATE SET
     name = excluded.name,
     place = excluded.place,
@@ -60,3 +69,108 @@ ON CONFLICT(link) DO UPDATE SET
     -- artist = excluded.artist,
     -- category = excluded.category,
     -- artist_url = excluded.artist_url,
+
+-- name: EnqueueMessage :exec
+INSERT INTO outbox (event_id, kind, transport, receiver, revision, next_retry_at) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(event_id, kind, transport, receiver, revision) DO NOTHING;
+
+-- name: GetDueMessages :many
+SELECT * FROM outbox
+    WHERE delivered_at IS NULL
+    AND attempts < sqlc.arg(max_attempts)
+    AND datetime(next_retry_at) <= datetime(sqlc.arg(now))
+ORDER BY id;
+
+-- name: MarkMessageDelivered :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = NULL, delivered_at = ? WHERE id = ?;
+
+-- name: MarkMessageFailed :exec
+UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_retry_at = ? WHERE id = ?;
+
+-- name: DeleteMessage :exec
+DELETE FROM outbox WHERE id = ?;
+
+-- name: CountUndeliveredMessages :one
+SELECT COUNT(*) FROM outbox WHERE event_id = ? AND kind = ? AND revision = ? AND delivered_at IS NULL;
+
+-- name: CreateStatusChange :exec
+INSERT INTO status_changes (event_id, old_status, new_status, kind) VALUES (?, ?, ?, ?);
+
+-- name: GetStatusChange :one
+SELECT * FROM status_changes WHERE id = ?;
+
+-- name: GetStatusChanges :many
+SELECT * FROM status_changes WHERE event_id = ? ORDER BY id;
+
+-- name: GetUnannouncedStatusChanges :many
+SELECT status_changes.* FROM status_changes
+JOIN events ON events.id = status_changes.event_id
+WHERE status_changes.announced_at IS NULL AND status_changes.kind != '' AND DATE(events.date) >= DATE(?)
+ORDER BY status_changes.id;
+
+-- name: MarkStatusChangeAnnounced :exec
+UPDATE status_changes SET announced_at = ? WHERE id = ?;
+
+-- name: CreateCrawlRun :execlastid
+INSERT INTO crawl_runs (source, event_count) VALUES (?, ?);
+
+-- name: AddCrawlRunLink :exec
+INSERT OR IGNORE INTO crawl_run_links (crawl_run_id, link) VALUES (?, ?);
+
+-- name: GetReappearedEvents :many
+SELECT * FROM events
+WHERE source = ? AND (missing_runs > 0 OR removed_at IS NOT NULL)
+AND link IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = ?)
+ORDER BY date;
+
+-- name: MarkEventSeen :exec
+UPDATE events SET missing_runs = 0, removed_at = NULL WHERE id = ?;
+
+-- name: GetUnseenEvents :many
+SELECT * FROM events
+WHERE source = ? AND removed_at IS NULL AND DATE(date) >= DATE(?)
+AND link NOT IN (SELECT link FROM crawl_run_links WHERE crawl_run_id = ?)
+ORDER BY date;
+
+-- name: IncrementMissingRuns :exec
+UPDATE events SET missing_runs = missing_runs + 1 WHERE id = ?;
+
+-- name: GetMissingEvents :many
+SELECT * FROM events WHERE source = ? AND removed_at IS NULL AND missing_runs >= ? ORDER BY date;
+
+-- name: MarkEventRemoved :exec
+UPDATE events SET removed_at = ? WHERE id = ?;
+
+-- name: CreateEventRevision :exec
+INSERT INTO event_revisions (event_id, field, old_value, new_value) VALUES (?, ?, ?, ?);
+
+-- name: GetEventRevisions :many
+SELECT * FROM event_revisions WHERE event_id = ? ORDER BY id;
+
+-- name: CreatePostponement :exec
+INSERT INTO event_postponements (event_id, previous_date, new_date) VALUES (?, ?, ?);
+
+-- name: GetPostponements :many
+SELECT * FROM event_postponements WHERE event_id = ? ORDER BY id;
+
+-- name: CreateMetadataFailure :exec
+INSERT INTO metadata_failures (event_id, raw_response, error) VALUES (?, ?, ?);
+
+-- name: GetMetadataFailures :many
+SELECT * FROM metadata_failures WHERE event_id = ? ORDER BY id;
+
+-- name: GetLookup :one
+SELECT value FROM lookup_cache
+    WHERE kind = sqlc.arg(kind)
+    AND key = sqlc.arg(key)
+    AND datetime(expires_at) > datetime(sqlc.arg(now));
+
+-- name: PutLookup :exec
+INSERT INTO lookup_cache (kind, key, value, expires_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(kind, key) DO UPDATE SET
+    value = excluded.value,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteExpiredLookups :exec
+DELETE FROM lookup_cache WHERE datetime(expires_at) <= datetime(?);